@@ -0,0 +1,264 @@
+package app
+
+import (
+	"archive/zip"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/ficsitcli"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type CrashReport struct {
+	File      string    `json:"file"`
+	CreatedAt time.Time `json:"createdAt"`
+	Install   string    `json:"install"`
+	Profile   string    `json:"profile"`
+	ExitCode  *int      `json:"exitCode"`
+	// CrashFolder is the name of the folder in Saved/Crashes, empty if the game did not write one
+	CrashFolder string `json:"crashFolder"`
+}
+
+// crashContext is the information about the crash added to the debug info
+type crashContext struct {
+	session     ficsitcli.GameSession
+	crashFolder string
+	// lockfile is the lockfile of the install that crashed, which might not be the selected one
+	lockfile *resolver.LockFile
+}
+
+const (
+	maxCrashReports = 5
+	// Full memory dumps can be several GB, and are not useful without the matching binaries anyway
+	maxCrashFileSize = 64 * 1024 * 1024
+	// crashContextFile is written by the engine with the crash details, but also with the account and machine IDs
+	crashContextFile = "CrashContext.runtime-xml"
+)
+
+var crashContextIdentityRegex = regexp.MustCompile(`(?i)<(UserName|LoginId|EpicAccountId|MachineId|UserDescription|UserActivityHint)>[^<]*</`)
+
+func crashReportsDir() string {
+	return filepath.Join(viper.GetString("smm-local-dir"), "crashReports")
+}
+
+// WatchCrashes generates a debug info bundle every time the game exits abnormally
+func (a *app) WatchCrashes() {
+	ficsitcli.OnGameExit(a.handleGameExit)
+}
+
+func (a *app) handleGameExit(session ficsitcli.GameSession) {
+	if session.Install == "" {
+		return
+	}
+	metadata, ok := ficsitcli.FicsitCLI.GetInstallationsMetadata()[session.Install]
+	if !ok || metadata.Info == nil {
+		return
+	}
+
+	crashFolder, err := findNewestCrashFolder(filepath.Join(metadata.Info.SavedPath, "Crashes"), session.StartTime)
+	if err != nil {
+		slog.Warn("failed to look for crash folder", slog.Any("error", err))
+	}
+
+	// The exit code is only known on some platforms, so a new crash folder is also treated as a crash
+	crashed := crashFolder != "" || (session.ExitCode != nil && *session.ExitCode != 0)
+	if !crashed {
+		return
+	}
+
+	l := slog.With(slog.String("install", session.Install), slog.String("crashFolder", crashFolder))
+	l.Info("game crashed, generating crash report")
+
+	err = os.MkdirAll(crashReportsDir(), 0o755)
+	if err != nil {
+		l.Error("failed to create crash reports directory", slog.Any("error", err))
+		return
+	}
+
+	createdAt := time.Now()
+	filename := filepath.Join(crashReportsDir(), fmt.Sprintf("SMMCrash-%s.zip", createdAt.UTC().Format("2006-01-02-15-04-05")))
+	crash := &crashContext{
+		session: session,
+	}
+	// Read it right away, so it still matches the mods the game ran with
+	crash.lockfile, err = ficsitcli.FicsitCLI.GetInstallLockfile(session.Install)
+	if err != nil {
+		l.Warn("failed to read lockfile of crashed install", slog.Any("error", err))
+	}
+	if crashFolder != "" {
+		crash.crashFolder = filepath.Join(metadata.Info.SavedPath, "Crashes", crashFolder)
+	}
+
+	err = a.generateAndSaveDebugInfo(filename, crash)
+	if err != nil {
+		l.Error("failed to generate crash report", slog.Any("error", err))
+		return
+	}
+
+	pruneCrashReports()
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "crashReportReady", CrashReport{
+		File:        filename,
+		CreatedAt:   createdAt,
+		Install:     session.Install,
+		Profile:     session.Profile,
+		ExitCode:    session.ExitCode,
+		CrashFolder: crashFolder,
+	})
+}
+
+// findNewestCrashFolder returns the name of the newest crash folder created after the game started
+func findNewestCrashFolder(crashesDir string, after time.Time) (string, error) {
+	entries, err := os.ReadDir(crashesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read crashes directory: %w", err)
+	}
+
+	var newest string
+	var newestTime time.Time
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(after) || info.ModTime().Before(newestTime) {
+			continue
+		}
+		newest = entry.Name()
+		newestTime = info.ModTime()
+	}
+	return newest, nil
+}
+
+func addCrashContext(writer *zip.Writer, crash *crashContext) error {
+	session := crash.session
+	session.Install = utils.RedactPath(session.Install)
+	session.Executable = utils.RedactPath(session.Executable)
+
+	sessionBytes, err := utils.JSONMarshal(session, 2)
+	if err != nil {
+		return fmt.Errorf("failed to marshal game session: %w", err)
+	}
+	sessionFile, err := writer.Create("crash/session.json")
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+	_, err = sessionFile.Write(sessionBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+
+	if crash.lockfile != nil {
+		lockfileBytes, err := utils.JSONMarshal(crash.lockfile, 2)
+		if err != nil {
+			return fmt.Errorf("failed to marshal lockfile: %w", err)
+		}
+		lockfileFile, err := writer.Create("crash/lockfile.json")
+		if err != nil {
+			return fmt.Errorf("failed to create lockfile file: %w", err)
+		}
+		_, err = lockfileFile.Write(lockfileBytes)
+		if err != nil {
+			return fmt.Errorf("failed to write lockfile file: %w", err)
+		}
+	}
+
+	if crash.crashFolder == "" {
+		return nil
+	}
+
+	zipFolder := "crash/" + filepath.Base(crash.crashFolder)
+	return filepath.WalkDir(crash.crashFolder, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if info.Size() > maxCrashFileSize {
+			slog.Info("skipping large crash file", slog.String("file", entry.Name()), slog.Int64("size", info.Size()))
+			return nil
+		}
+		rel, err := filepath.Rel(crash.crashFolder, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		zipPath := zipFolder + "/" + filepath.ToSlash(rel)
+		if strings.HasSuffix(strings.ToLower(entry.Name()), ".log") {
+			// Logs are redacted the same as the FactoryGame.log
+			bytes, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			return addLogFromBytes(writer, bytes, zipPath)
+		}
+		if strings.EqualFold(entry.Name(), crashContextFile) {
+			bytes, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			return addLogFromBytes(writer, redactCrashContext(bytes), zipPath)
+		}
+		return utils.AddFileToZip(writer, path, zipPath) //nolint:wrapcheck
+	})
+}
+
+// redactCrashContext removes the user and machine identifiers from the engine crash context,
+// and the home directory from the paths in it, which usually contains the user name
+func redactCrashContext(bytes []byte) []byte {
+	bytes = crashContextIdentityRegex.ReplaceAll(bytes, []byte("<${1}>REDACTED</"))
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return bytes
+	}
+	for _, homePath := range []string{home, filepath.ToSlash(home)} {
+		bytes = regexp.MustCompile(`(?i)`+regexp.QuoteMeta(homePath)).ReplaceAll(bytes, []byte("~"))
+	}
+	return bytes
+}
+
+func pruneCrashReports() {
+	entries, err := os.ReadDir(crashReportsDir())
+	if err != nil {
+		slog.Warn("failed to read crash reports directory", slog.Any("error", err))
+		return
+	}
+	reports := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), "SMMCrash-") && strings.HasSuffix(entry.Name(), ".zip") {
+			reports = append(reports, entry.Name())
+		}
+	}
+	if len(reports) <= maxCrashReports {
+		return
+	}
+	// The names contain the timestamp, so they sort by age
+	slices.Sort(reports)
+	for _, report := range reports[:len(reports)-maxCrashReports] {
+		err := os.Remove(filepath.Join(crashReportsDir(), report))
+		if err != nil {
+			slog.Warn("failed to remove old crash report", slog.String("file", report), slog.Any("error", err))
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	ficsitCli "github.com/satisfactorymodding/ficsit-cli/cli"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
 	"github.com/spf13/viper"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 
@@ -136,7 +137,8 @@ func getLogNameForInstall(install *common.Installation) string {
 	return fmt.Sprintf("FactoryGame_%s_%s_%s_%s.log", first8, install.Location, install.Branch, install.Type)
 }
 
-func addMetadata(writer *zip.Writer) error {
+// addMetadata writes metadata.json. When crash is set, the installed mods are the ones of the install that crashed
+func addMetadata(writer *zip.Writer, crash *crashContext) error {
 	installs := ficsitcli.FicsitCLI.GetInstallations()
 	selectedInstallInstance := ficsitcli.FicsitCLI.GetSelectedInstall()
 	metadataInstalls := make([]*MetadataInstallation, 0)
@@ -172,9 +174,15 @@ func addMetadata(writer *zip.Writer) error {
 		metadataProfiles = append(metadataProfiles, p)
 	}
 
-	lockfile, err := ficsitcli.FicsitCLI.GetSelectedInstallLockfile()
-	if err != nil {
-		slog.Warn("failed to get lockfile for debuginfo", slog.Any("error", err))
+	var lockfile *resolver.LockFile
+	if crash != nil {
+		lockfile = crash.lockfile
+	} else {
+		var err error
+		lockfile, err = ficsitcli.FicsitCLI.GetSelectedInstallLockfile()
+		if err != nil {
+			slog.Warn("failed to get lockfile for debuginfo", slog.Any("error", err))
+		}
 	}
 
 	metadataInstalledMods := make(map[string]string)
@@ -216,7 +224,8 @@ func addMetadata(writer *zip.Writer) error {
 	return nil
 }
 
-func (a *app) generateAndSaveDebugInfo(filename string) error {
+// generateAndSaveDebugInfo writes the debug info zip. crash is optional, and adds the crash details when set
+func (a *app) generateAndSaveDebugInfo(filename string, crash *crashContext) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
@@ -227,11 +236,18 @@ func (a *app) generateAndSaveDebugInfo(filename string) error {
 
 	addFactoryGameLogs(writer)
 
-	err = addMetadata(writer)
+	err = addMetadata(writer, crash)
 	if err != nil {
 		slog.Warn("failed to add metadata to debuginfo zip", slog.Any("error", err))
 	}
 
+	if crash != nil {
+		err = addCrashContext(writer, crash)
+		if err != nil {
+			slog.Warn("failed to add crash context to debuginfo zip", slog.Any("error", err))
+		}
+	}
+
 	// Add SMM log last, as it may list errors from previous steps
 	err = utils.AddFileToZip(writer, viper.GetString("log-file"), "SatisfactoryModManager.log")
 	if err != nil {
@@ -261,7 +277,7 @@ func (a *app) GenerateDebugInfo() (bool, error) {
 		return false, nil
 	}
 
-	err = a.generateAndSaveDebugInfo(filename)
+	err = a.generateAndSaveDebugInfo(filename, nil)
 	if err != nil {
 		slog.Error("failed to generate debug info", slog.Any("error", err))
 		return false, fmt.Errorf("failed to generate debug info: %w", err)
@@ -4,6 +4,8 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/ficsitcli"
 )
 
 func (a *app) GetVersion() string {
@@ -18,8 +20,9 @@ func (a *app) GetDate() string {
 	return viper.GetString("date")
 }
 
-func (a *app) GetAPIEndpoint() string {
-	return viper.GetString("api-base") + viper.GetString("graphql-api")
+// GetAPIEndpoints returns the GraphQL URLs of the repository endpoints, in the order the frontend should try them
+func (a *app) GetAPIEndpoints() []string {
+	return ficsitcli.FicsitCLI.GetActiveRepositoryEndpoints()
 }
 
 func (a *app) GetSiteEndpoint() string {
@@ -111,6 +111,10 @@ func (a *app) ExternalImportProfile(path string) {
 	wailsRuntime.EventsEmit(common.AppContext, "externalImportProfile", path)
 }
 
+func (a *app) ExternalInstallLocalMod(path string) {
+	wailsRuntime.EventsEmit(common.AppContext, "externalInstallLocalMod", path)
+}
+
 func (a *app) Show() {
 	wailsRuntime.WindowUnminimise(common.AppContext)
 	wailsRuntime.Show(common.AppContext)
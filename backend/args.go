@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/app"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/ficsitcli"
 )
 
 func ProcessArguments(args []string) {
@@ -50,5 +51,9 @@ func handleFile(path string) error {
 		app.App.ExternalImportProfile(path)
 		return nil
 	}
+	if ficsitcli.IsLocalModArchive(path) {
+		app.App.ExternalInstallLocalMod(path)
+		return nil
+	}
 	return fmt.Errorf("unknown file type %s", path)
 }
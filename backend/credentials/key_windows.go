@@ -0,0 +1,40 @@
+package credentials
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// SystemProtectedKey is set when the key used without a passphrase is encrypted by the OS for the current user
+const SystemProtectedKey = true
+
+// protectKey encrypts the key with DPAPI, so that it can only be read by the same Windows user
+func protectKey(key []byte) ([]byte, error) {
+	var out windows.DataBlob
+	err := windows.CryptProtectData(newDataBlob(key), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to protect credential key: %w", err)
+	}
+	return takeDataBlob(out), nil
+}
+
+func unprotectKey(data []byte) ([]byte, error) {
+	var out windows.DataBlob
+	err := windows.CryptUnprotectData(newDataBlob(data), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unprotect credential key: %w", err)
+	}
+	return takeDataBlob(out), nil
+}
+
+func newDataBlob(data []byte) *windows.DataBlob {
+	return &windows.DataBlob{Size: uint32(len(data)), Data: unsafe.SliceData(data)}
+}
+
+// takeDataBlob copies the output of DPAPI and frees it
+func takeDataBlob(blob windows.DataBlob) []byte {
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(blob.Data))) //nolint:errcheck
+	return append([]byte{}, unsafe.Slice(blob.Data, blob.Size)...)
+}
@@ -0,0 +1,365 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+var (
+	ErrLocked            = errors.New("the credential store is locked")
+	ErrWrongPassphrase   = errors.New("wrong passphrase")
+	errUnsupportedFormat = errors.New("unsupported credential store version")
+)
+
+const (
+	storeFileName = "credentials.json"
+	// keyFileName holds the random key used while no passphrase is set, encrypted by the OS where SystemProtectedKey is set.
+	// Otherwise it only keeps the credentials out of files that are shared, such as installations.json and debug info
+	keyFileName  = "credentials.key"
+	storeVersion = 1
+	keySize      = 32
+	saltSize     = 16
+
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+// storeFile is the encrypted format on disk. Data is the AES-GCM encrypted JSON of the credentials
+type storeFile struct {
+	Version    int    `json:"version"`
+	Passphrase bool   `json:"passphrase"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Store keeps credentials encrypted on disk, either with a key derived from a passphrase,
+// or with a random key stored next to it, see SystemProtectedKey
+type Store struct {
+	dir string
+
+	passphrase  bool
+	salt        []byte
+	key         []byte
+	credentials map[string]Credential
+
+	lock sync.Mutex
+}
+
+// Open loads the store in dir. Stores without a passphrase are unlocked immediately,
+// the others stay locked until Unlock is called.
+// If the store cannot be read or decrypted, the locked store is returned along with the error,
+// so that the remote installs are unavailable instead of SMM failing to start. Reset then starts over with an empty store
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+
+	file, err := s.readFile()
+	if err != nil {
+		return s, err
+	}
+	if file == nil {
+		key, err := s.machineKey()
+		if err != nil {
+			return s, err
+		}
+		s.key = key
+		s.credentials = make(map[string]Credential)
+		return s, nil
+	}
+
+	s.passphrase = file.Passphrase
+	s.salt = file.Salt
+	if s.passphrase {
+		return s, nil
+	}
+
+	key, err := s.machineKey()
+	if err != nil {
+		return s, err
+	}
+	credentials, err := decrypt(key, file)
+	if err != nil {
+		return s, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	s.key = key
+	s.credentials = credentials
+	return s, nil
+}
+
+// Reset deletes every credential and the key, and leaves the store unlocked without a passphrase
+func (s *Store) Reset() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, name := range []string{storeFileName, keyFileName} {
+		err := os.Remove(filepath.Join(s.dir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	key, err := s.machineKey()
+	if err != nil {
+		return err
+	}
+	s.passphrase = false
+	s.salt = nil
+	s.key = key
+	s.credentials = make(map[string]Credential)
+	return nil
+}
+
+func (s *Store) Locked() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.credentials == nil
+}
+
+func (s *Store) PassphraseProtected() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.passphrase
+}
+
+// Unlock decrypts a passphrase protected store
+func (s *Store) Unlock(passphrase string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.credentials != nil {
+		return nil
+	}
+	file, err := s.readFile()
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return fmt.Errorf("credential store file is missing")
+	}
+
+	key := deriveKey(passphrase, file.Salt)
+	credentials, err := decrypt(key, file)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	s.key = key
+	s.credentials = credentials
+	return nil
+}
+
+// SetPassphrase encrypts the store with a new passphrase. An empty passphrase removes the protection
+func (s *Store) SetPassphrase(passphrase string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.credentials == nil {
+		return ErrLocked
+	}
+
+	if passphrase == "" {
+		key, err := s.machineKey()
+		if err != nil {
+			return err
+		}
+		s.passphrase = false
+		s.salt = nil
+		s.key = key
+		return s.saveLocked()
+	}
+
+	salt := make([]byte, saltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	s.passphrase = true
+	s.salt = salt
+	s.key = deriveKey(passphrase, salt)
+	return s.saveLocked()
+}
+
+func (s *Store) Get(id string) (Credential, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.credentials == nil {
+		return Credential{}, false, ErrLocked
+	}
+	credential, ok := s.credentials[id]
+	return credential, ok, nil
+}
+
+func (s *Store) Set(id string, credential Credential) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.credentials == nil {
+		return ErrLocked
+	}
+	s.credentials[id] = credential
+	return s.saveLocked()
+}
+
+func (s *Store) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.credentials == nil {
+		return ErrLocked
+	}
+	if _, ok := s.credentials[id]; !ok {
+		return nil
+	}
+	delete(s.credentials, id)
+	return s.saveLocked()
+}
+
+func (s *Store) saveLocked() error {
+	plaintext, err := json.Marshal(s.credentials)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	fileBytes, err := utils.JSONMarshal(storeFile{
+		Version:    storeVersion,
+		Passphrase: s.passphrase,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Data:       gcm.Seal(nil, nonce, plaintext, nil),
+	}, 2)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential store: %w", err)
+	}
+
+	// Written to a temporary file first, so that a failed write does not lose every credential
+	storePath := filepath.Join(s.dir, storeFileName)
+	err = os.WriteFile(storePath+".tmp", fileBytes, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write credential store: %w", err)
+	}
+	err = os.Rename(storePath+".tmp", storePath)
+	if err != nil {
+		return fmt.Errorf("failed to replace credential store: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) readFile() (*storeFile, error) {
+	fileBytes, err := os.ReadFile(filepath.Join(s.dir, storeFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read credential store: %w", err)
+	}
+	var file storeFile
+	err = json.Unmarshal(fileBytes, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse credential store: %w", err)
+	}
+	if file.Version != storeVersion {
+		return nil, fmt.Errorf("%w: %d", errUnsupportedFormat, file.Version)
+	}
+	return &file, nil
+}
+
+// machineKey reads the random key used without a passphrase, creating it if needed
+func (s *Store) machineKey() ([]byte, error) {
+	keyPath := filepath.Join(s.dir, keyFileName)
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		// Key files written before the key was protected hold the key as is
+		if len(data) == keySize && SystemProtectedKey {
+			return data, s.writeMachineKey(data)
+		}
+		key, err := unprotectKey(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid credential key file")
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read credential key: %w", err)
+	}
+
+	key := make([]byte, keySize)
+	_, err = rand.Read(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate credential key: %w", err)
+	}
+	return key, s.writeMachineKey(key)
+}
+
+func (s *Store) writeMachineKey(key []byte) error {
+	data, err := protectKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(s.dir, keyFileName), data, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write credential key: %w", err)
+	}
+	return nil
+}
+
+func deriveKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, keySize)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+func decrypt(key []byte, file *storeFile) (map[string]Credential, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	credentials := make(map[string]Credential)
+	err = json.Unmarshal(plaintext, &credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	return credentials, nil
+}
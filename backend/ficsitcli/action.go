@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
 )
 
@@ -91,9 +92,30 @@ func (f *ficsitCLI) Apply() error {
 	return f.action(ActionApply, newSimpleItem(*profileName), f.apply)
 }
 
+// discardTaskUpdates returns a task channel for an apply that runs after the action's own channel was closed
+func discardTaskUpdates() chan<- taskUpdate {
+	taskChannel := make(chan taskUpdate)
+	go func() {
+		for range taskChannel {
+		}
+	}()
+	return taskChannel
+}
+
 func (f *ficsitCLI) apply(l *slog.Logger, taskChannel chan<- taskUpdate) error {
 	installsToApply, profile, err := f.getInstallsToApply()
 	if err != nil {
+		close(taskChannel)
+		return err
+	}
+
+	installPaths := make([]string, 0, len(installsToApply))
+	for _, install := range installsToApply {
+		installPaths = append(installPaths, install.install.Path)
+	}
+	err = f.checkApplyAllowed(installPaths...)
+	if err != nil {
+		close(taskChannel)
 		return err
 	}
 
@@ -102,7 +124,7 @@ func (f *ficsitCLI) apply(l *slog.Logger, taskChannel chan<- taskUpdate) error {
 		targetsUsingProfile[resolver.TargetName(install.targetName)] = true
 	}
 
-	profile.RequiredTargets = maps.Keys(targetsUsingProfile)
+	profile.RequiredTargets = mergeTargets(maps.Keys(targetsUsingProfile), f.GetProfileExtraTargets(profile.Name))
 	err = f.ficsitCli.Profiles.Save()
 	if err != nil {
 		l.Error("failed to save profile", slog.Any("error", err))
@@ -113,6 +135,20 @@ func (f *ficsitCLI) apply(l *slog.Logger, taskChannel chan<- taskUpdate) error {
 
 	defer close(taskChannel)
 
+	if settings.Settings.SaveBackups {
+		for _, installTarget := range installsToApply {
+			err := f.backupSavesBeforeApply(l, installTarget, profile)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	restartServers, err := f.stopServersForApply(l, installPaths...)
+	if err != nil {
+		return err
+	}
+
 	var errg errgroup.Group
 	var wg sync.WaitGroup
 
@@ -121,30 +157,7 @@ func (f *ficsitCLI) apply(l *slog.Logger, taskChannel chan<- taskUpdate) error {
 		errg.Go(func() error {
 			defer wg.Done()
 
-			installChannel := make(chan cli.InstallUpdate)
-
-			go func() {
-				for update := range installChannel {
-					switch update.Type {
-					case cli.InstallUpdateTypeModDownload:
-						taskChannel <- taskUpdate{
-							taskName: fmt.Sprintf("%s:%s:%s:download", update.Item.Mod, update.Item.Version, installTarget.targetName),
-							progress: utils.Progress{
-								Current: update.Progress.Completed,
-								Total:   update.Progress.Total,
-							},
-						}
-					case cli.InstallUpdateTypeModExtract:
-						taskChannel <- taskUpdate{
-							taskName: fmt.Sprintf("%s:%s:%s:extract", update.Item.Mod, update.Item.Version, installTarget.targetName),
-							progress: utils.Progress{
-								Current: update.Progress.Completed,
-								Total:   update.Progress.Total,
-							},
-						}
-					}
-				}
-			}()
+			installChannel := forwardInstallUpdates(installTarget.targetName, taskChannel)
 
 			installErr := installTarget.install.Install(f.ficsitCli, installChannel)
 			if installErr != nil {
@@ -161,12 +174,46 @@ func (f *ficsitCLI) apply(l *slog.Logger, taskChannel chan<- taskUpdate) error {
 	if err := errg.Wait(); err != nil {
 		// Ensure everything is finished, but return first error
 		wg.Wait()
+		// The servers are left stopped, since their mods might be partially installed
 		return err //nolint:wrapcheck
 	}
 
+	f.evictCache(l)
+	f.restartServersAfterApply(l, restartServers)
+
 	return nil
 }
 
+// forwardInstallUpdates returns a channel for cli.Installation.Install, which forwards the download and extract progress as tasks
+func forwardInstallUpdates(targetName string, taskChannel chan<- taskUpdate) chan cli.InstallUpdate {
+	installChannel := make(chan cli.InstallUpdate)
+
+	go func() {
+		for update := range installChannel {
+			switch update.Type {
+			case cli.InstallUpdateTypeModDownload:
+				taskChannel <- taskUpdate{
+					taskName: fmt.Sprintf("%s:%s:%s:download", update.Item.Mod, update.Item.Version, targetName),
+					progress: utils.Progress{
+						Current: update.Progress.Completed,
+						Total:   update.Progress.Total,
+					},
+				}
+			case cli.InstallUpdateTypeModExtract:
+				taskChannel <- taskUpdate{
+					taskName: fmt.Sprintf("%s:%s:%s:extract", update.Item.Mod, update.Item.Version, targetName),
+					progress: utils.Progress{
+						Current: update.Progress.Completed,
+						Total:   update.Progress.Total,
+					},
+				}
+			}
+		}
+	}()
+
+	return installChannel
+}
+
 type installWithTarget struct {
 	install    *cli.Installation
 	targetName string
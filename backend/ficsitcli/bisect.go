@@ -0,0 +1,582 @@
+package ficsitcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type BisectState struct {
+	Install         string   `json:"install"`
+	OriginalProfile string   `json:"originalProfile"`
+	BisectProfile   string   `json:"bisectProfile"`
+	Round           int      `json:"round"`
+	Suspects        []string `json:"suspects"`
+	Testing         []string `json:"testing"`
+	Enabled         []string `json:"enabled"`
+	Cleared         []string `json:"cleared"`
+	Culprit         *string  `json:"culprit"`
+	Done            bool     `json:"done"`
+}
+
+type bisectSession struct {
+	state BisectState
+	// order contains the candidate mods sorted so that every mod comes after the profile mods it depends on,
+	// which guarantees that enabling a prefix of it never requires a mod outside that prefix
+	order []string
+	// requires contains, for every candidate mod, the other profile mods it transitively depends on
+	requires map[string][]string
+}
+
+// savedBisectSession is the bisect session as it is saved, so that it can be resumed after SMM is restarted
+type savedBisectSession struct {
+	State    BisectState         `json:"state"`
+	Order    []string            `json:"order"`
+	Requires map[string][]string `json:"requires"`
+}
+
+const bisectSessionFileName = "bisect.json"
+
+func bisectProfileName(profile string) string {
+	return profile + " (bisect)"
+}
+
+// StartBisect clones the selected profile and starts a bisection session on the clone,
+// so that the original profile and its lockfile are never modified
+func (f *ficsitCLI) StartBisect() error {
+	return f.action(ActionBisect, noItem, func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		l, err := f.startBisectSession(l)
+		if err != nil {
+			close(taskChannel)
+			return err
+		}
+
+		err = f.applyBisectRound(l, taskChannel, f.bisect.state)
+		if err != nil {
+			// Without a first round there is nothing to report on, so the session is not kept
+			f.cancelBisect(l)
+			return err
+		}
+		return nil
+	})
+}
+
+// startBisectSession creates the bisect profile and the session, without applying it
+func (f *ficsitCLI) startBisectSession(l *slog.Logger) (*slog.Logger, error) {
+	if f.bisect != nil {
+		return nil, fmt.Errorf("a bisect session is already in progress")
+	}
+
+	selectedInstallation := f.GetSelectedInstall()
+	if selectedInstallation == nil {
+		return nil, fmt.Errorf("no installation selected")
+	}
+	if selectedInstallation.Vanilla {
+		return nil, fmt.Errorf("mods are disabled for the selected installation")
+	}
+
+	l = l.With(
+		slog.String("install", selectedInstallation.Path),
+		slog.String("profile", selectedInstallation.Profile),
+	)
+
+	originalProfileName := selectedInstallation.Profile
+	originalProfile := f.GetProfile(originalProfileName)
+	if originalProfile == nil {
+		return nil, fmt.Errorf("profile %s not found", originalProfileName)
+	}
+
+	lockfile, err := selectedInstallation.LockFile(f.ficsitCli)
+	if err != nil {
+		l.Error("failed to get lockfile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get lockfile: %w", err)
+	}
+	if lockfile == nil {
+		return nil, fmt.Errorf("the selected profile has not been applied yet")
+	}
+
+	candidates := make([]string, 0, len(originalProfile.Mods))
+	for modReference, mod := range originalProfile.Mods {
+		if mod.Enabled {
+			candidates = append(candidates, modReference)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil, fmt.Errorf("at least two enabled mods are required to bisect")
+	}
+
+	requires, err := f.getProfileModRequirements(lockfile, candidates)
+	if err != nil {
+		l.Error("failed to get mod dependencies", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get mod dependencies: %w", err)
+	}
+
+	bisectProfile := bisectProfileName(originalProfileName)
+	if f.GetProfile(bisectProfile) != nil {
+		// Left over from a session whose saved state was lost
+		l.Warn("removing leftover bisect profile")
+		_, err = f.removeBisectProfile(l, BisectState{OriginalProfile: originalProfileName, BisectProfile: bisectProfile})
+		if err != nil {
+			return nil, err
+		}
+	}
+	clone, err := f.ficsitCli.Profiles.AddProfile(bisectProfile)
+	if err != nil {
+		l.Error("failed to create bisect profile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to create bisect profile: %w", err)
+	}
+	clone.Mods = make(map[string]cli.ProfileMod, len(originalProfile.Mods))
+	for modReference, mod := range originalProfile.Mods {
+		// Disabled mods drop out of the lockfile, so they are pinned to keep their version when enabled again
+		if lockedMod, ok := lockfile.Mods[modReference]; ok {
+			mod.Version = "=" + lockedMod.Version
+		}
+		clone.Mods[modReference] = mod
+	}
+	clone.RequiredTargets = slices.Clone(originalProfile.RequiredTargets)
+	if extraTargets, ok := settings.Settings.ProfileExtraTargets[originalProfileName]; ok {
+		settings.Settings.ProfileExtraTargets[bisectProfile] = slices.Clone(extraTargets)
+		_ = settings.SaveSettings()
+	}
+
+	err = selectedInstallation.SetProfile(f.ficsitCli, bisectProfile)
+	if err != nil {
+		_ = f.ficsitCli.Profiles.DeleteProfile(bisectProfile)
+		f.deleteProfileExtraTargets(bisectProfile)
+		l.Error("failed to set profile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to set profile: %w", err)
+	}
+
+	// Start from the original lockfile, so that mods are not updated while bisecting
+	err = selectedInstallation.WriteLockFile(f.ficsitCli, lockfile.Clone())
+	if err != nil {
+		_ = selectedInstallation.SetProfile(f.ficsitCli, originalProfileName)
+		_ = f.ficsitCli.Profiles.DeleteProfile(bisectProfile)
+		f.deleteProfileExtraTargets(bisectProfile)
+		l.Error("failed to write lockfile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	order := sortedBisectCandidates(candidates, requires)
+	f.bisect = &bisectSession{
+		state: BisectState{
+			Install:         selectedInstallation.Path,
+			OriginalProfile: originalProfileName,
+			BisectProfile:   bisectProfile,
+			Suspects:        slices.Clone(order),
+			Cleared:         []string{},
+		},
+		order:    order,
+		requires: requires,
+	}
+
+	err = f.ficsitCli.Profiles.Save()
+	if err != nil {
+		l.Error("failed to save profile", slog.Any("error", err))
+	}
+	err = f.ficsitCli.Installations.Save()
+	if err != nil {
+		l.Error("failed to save installations", slog.Any("error", err))
+	}
+
+	f.EmitGlobals()
+	return l, nil
+}
+
+func (f *ficsitCLI) GetBisectState() *BisectState {
+	if f.bisect == nil {
+		return nil
+	}
+	state := f.bisect.state
+	return &state
+}
+
+// BisectReportGood marks the currently enabled mods as working
+func (f *ficsitCLI) BisectReportGood() error {
+	return f.bisectReport(false)
+}
+
+// BisectReportBad marks the currently enabled mods as containing the culprit
+func (f *ficsitCLI) BisectReportBad() error {
+	return f.bisectReport(true)
+}
+
+func (f *ficsitCLI) bisectReport(bad bool) error {
+	return f.action(ActionBisect, noItem, func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		if f.bisect == nil {
+			close(taskChannel)
+			return fmt.Errorf("no bisect session in progress")
+		}
+		if f.bisect.state.Done {
+			close(taskChannel)
+			return fmt.Errorf("bisect session already finished")
+		}
+
+		// The session is only updated once the next round is applied, so a failed apply can be reported again
+		state := f.bisect.state
+		state.Suspects = slices.Clone(state.Suspects)
+		state.Cleared = slices.Clone(state.Cleared)
+
+		l = l.With(slog.Int("round", state.Round), slog.Bool("bad", bad))
+
+		if bad {
+			for _, mod := range state.Suspects {
+				if !slices.Contains(state.Testing, mod) {
+					state.Cleared = append(state.Cleared, mod)
+				}
+			}
+			state.Suspects = slices.Clone(state.Testing)
+		} else {
+			state.Cleared = append(state.Cleared, state.Testing...)
+			state.Suspects = slices.DeleteFunc(state.Suspects, func(mod string) bool {
+				return slices.Contains(state.Testing, mod)
+			})
+		}
+
+		state.Round++
+
+		switch len(state.Suspects) {
+		case 0:
+			// The remaining mods only fail together with already cleared mods
+			l.Info("bisect finished without a culprit")
+			state.Done = true
+			f.bisect.state = state
+			return f.finishBisect(l, taskChannel)
+		case 1:
+			l.Info("bisect found culprit", slog.String("culprit", state.Suspects[0]))
+			culprit := state.Suspects[0]
+			state.Culprit = &culprit
+			state.Done = true
+			f.bisect.state = state
+			return f.finishBisect(l, taskChannel)
+		}
+
+		return f.applyBisectRound(l, taskChannel, state)
+	})
+}
+
+// StopBisect ends the bisect session and restores the original profile, even if no culprit was found yet
+func (f *ficsitCLI) StopBisect() error {
+	return f.action(ActionBisect, noItem, func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		if f.bisect == nil {
+			close(taskChannel)
+			return nil
+		}
+		f.bisect.state.Done = true
+		return f.finishBisect(l, taskChannel)
+	})
+}
+
+// applyBisectRound enables the first half of the suspects of state, and makes state the current one if it was applied.
+// It closes taskChannel, like apply
+func (f *ficsitCLI) applyBisectRound(l *slog.Logger, taskChannel chan<- taskUpdate, state BisectState) error {
+	// Suspects keep the dependency order, so the first half only depends on itself or on cleared mods
+	state.Testing = slices.Clone(state.Suspects[:len(state.Suspects)/2])
+
+	enabled := make(map[string]bool)
+	for _, mod := range state.Testing {
+		enabled[mod] = true
+		for _, dependency := range f.bisect.requires[mod] {
+			enabled[dependency] = true
+		}
+	}
+
+	profile := f.GetProfile(state.BisectProfile)
+	if profile == nil {
+		close(taskChannel)
+		return fmt.Errorf("bisect profile %s not found", state.BisectProfile)
+	}
+	for _, mod := range f.bisect.order {
+		profile.SetModEnabled(mod, enabled[mod])
+	}
+
+	state.Enabled = make([]string, 0, len(enabled))
+	for _, mod := range f.bisect.order {
+		if enabled[mod] {
+			state.Enabled = append(state.Enabled, mod)
+		}
+	}
+
+	err := f.apply(l, taskChannel)
+	if err != nil {
+		l.Error("failed to apply bisect round", slog.Any("error", err))
+		// Go back to the mods of the current round
+		for _, mod := range f.bisect.order {
+			profile.SetModEnabled(mod, slices.Contains(f.bisect.state.Enabled, mod))
+		}
+		return err
+	}
+
+	f.bisect.state = state
+
+	err = f.ficsitCli.Profiles.Save()
+	if err != nil {
+		l.Error("failed to save profile", slog.Any("error", err))
+	}
+	err = f.bisect.save()
+	if err != nil {
+		l.Error("failed to save bisect session", slog.Any("error", err))
+	}
+
+	f.emitBisectState()
+	return nil
+}
+
+// finishBisect restores the original profile and applies it. It closes taskChannel, like apply
+func (f *ficsitCLI) finishBisect(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+	state := f.bisect.state
+
+	installation, err := f.removeBisectProfile(l, state)
+	if err != nil {
+		close(taskChannel)
+		return err
+	}
+	f.bisect = nil
+	removeBisectSession()
+
+	f.EmitGlobals()
+	wailsRuntime.EventsEmit(appCommon.AppContext, "bisectFinished", state)
+	wailsRuntime.EventsEmit(appCommon.AppContext, "bisectState", nil)
+
+	if installation == nil || f.GetSelectedInstall() != installation {
+		close(taskChannel)
+		return nil
+	}
+
+	err = f.apply(l, taskChannel)
+	if err != nil {
+		l.Error("failed to restore original mods", slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// cancelBisect removes a session that never got to its first round.
+// The failed round already closed the action's task channel, so the restore reports no progress
+func (f *ficsitCLI) cancelBisect(l *slog.Logger) {
+	installation, err := f.removeBisectProfile(l, f.bisect.state)
+	if err != nil {
+		return
+	}
+	f.bisect = nil
+	removeBisectSession()
+
+	f.EmitGlobals()
+
+	if installation != nil && f.GetSelectedInstall() == installation {
+		err = f.apply(l, discardTaskUpdates())
+		if err != nil {
+			l.Error("failed to restore original mods", slog.Any("error", err))
+		}
+	}
+}
+
+// removeBisectProfile switches the installs using the bisect profile back to the original one, then deletes it.
+// It returns the install the session was started on
+func (f *ficsitCLI) removeBisectProfile(l *slog.Logger, state BisectState) (*cli.Installation, error) {
+	for _, installation := range f.ficsitCli.Installations.Installations {
+		if installation.Profile != state.BisectProfile {
+			continue
+		}
+		err := installation.SetProfile(f.ficsitCli, state.OriginalProfile)
+		if err != nil {
+			l.Error("failed to restore original profile", slog.Any("error", err))
+			return nil, fmt.Errorf("failed to restore original profile: %w", err)
+		}
+
+		d, err := installation.GetDisk()
+		if err == nil {
+			err = d.Remove(profileLockfilePath(installation, state.BisectProfile))
+		}
+		if err != nil {
+			l.Warn("failed to remove bisect lockfile", slog.Any("error", err))
+		}
+	}
+
+	err := f.ficsitCli.Profiles.DeleteProfile(state.BisectProfile)
+	if err != nil {
+		l.Warn("failed to delete bisect profile", slog.Any("error", err))
+	}
+	f.deleteProfileExtraTargets(state.BisectProfile)
+
+	err = f.ficsitCli.Profiles.Save()
+	if err != nil {
+		l.Error("failed to save profile", slog.Any("error", err))
+	}
+	err = f.ficsitCli.Installations.Save()
+	if err != nil {
+		l.Error("failed to save installations", slog.Any("error", err))
+	}
+
+	return f.GetInstallation(state.Install), nil
+}
+
+// resumeBisect restores the bisect session that was in progress when SMM was closed.
+// If its install or profile is gone, the leftovers are cleaned up instead
+func (f *ficsitCLI) resumeBisect() {
+	session := loadBisectSession()
+	if session == nil {
+		return
+	}
+	l := slog.With(slog.String("task", "resumeBisect"), slog.String("install", session.state.Install))
+	if f.GetInstallation(session.state.Install) != nil && f.GetProfile(session.state.BisectProfile) != nil {
+		l.Info("resuming bisect session", slog.Int("round", session.state.Round))
+		f.bisect = session
+		return
+	}
+	l.Warn("bisect session cannot be resumed, restoring the original profile")
+	_, err := f.removeBisectProfile(l, session.state)
+	if err != nil {
+		return
+	}
+	removeBisectSession()
+}
+
+func loadBisectSession() *bisectSession {
+	fileBytes, err := os.ReadFile(filepath.Join(viper.GetString("smm-local-dir"), bisectSessionFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read bisect session", slog.Any("error", err))
+		}
+		return nil
+	}
+	var saved savedBisectSession
+	err = json.Unmarshal(fileBytes, &saved)
+	if err != nil || saved.State.BisectProfile == "" {
+		slog.Warn("failed to parse bisect session", slog.Any("error", err))
+		return nil
+	}
+	return &bisectSession{
+		state:    saved.State,
+		order:    saved.Order,
+		requires: saved.Requires,
+	}
+}
+
+func (s *bisectSession) save() error {
+	fileBytes, err := utils.JSONMarshal(savedBisectSession{
+		State:    s.state,
+		Order:    s.order,
+		Requires: s.requires,
+	}, 2)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bisect session: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(viper.GetString("smm-local-dir"), bisectSessionFileName), fileBytes, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write bisect session: %w", err)
+	}
+	return nil
+}
+
+func removeBisectSession() {
+	err := os.Remove(filepath.Join(viper.GetString("smm-local-dir"), bisectSessionFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove bisect session", slog.Any("error", err))
+	}
+}
+
+func (f *ficsitCLI) emitBisectState() {
+	wailsRuntime.EventsEmit(appCommon.AppContext, "bisectState", f.GetBisectState())
+}
+
+// getProfileModRequirements returns, for each of the candidate mods,
+// the other candidates it requires, following dependencies through mods that are not candidates
+func (f *ficsitCLI) getProfileModRequirements(lockfile *resolver.LockFile, candidates []string) (map[string][]string, error) {
+	directDependencies := make(map[string][]string, len(lockfile.Mods))
+	for modReference, lockedMod := range lockfile.Mods {
+		versions, err := f.ficsitCli.Provider.ModVersionsWithDependencies(context.TODO(), modReference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get versions of %s: %w", modReference, err)
+		}
+		for _, version := range versions {
+			if version.Version != lockedMod.Version {
+				continue
+			}
+			for _, dependency := range version.Dependencies {
+				if dependency.Optional || dependency.ModID == "FactoryGame" {
+					continue
+				}
+				directDependencies[modReference] = append(directDependencies[modReference], dependency.ModID)
+			}
+			break
+		}
+	}
+
+	requires := make(map[string][]string, len(candidates))
+	for _, candidate := range candidates {
+		visited := map[string]bool{candidate: true}
+		queue := slices.Clone(directDependencies[candidate])
+		for len(queue) > 0 {
+			mod := queue[0]
+			queue = queue[1:]
+			if visited[mod] {
+				continue
+			}
+			visited[mod] = true
+			if slices.Contains(candidates, mod) {
+				requires[candidate] = append(requires[candidate], mod)
+			}
+			queue = append(queue, directDependencies[mod]...)
+		}
+		sort.Strings(requires[candidate])
+	}
+	return requires, nil
+}
+
+// sortedBisectCandidates orders the candidates so that mods come after the candidates they require
+func sortedBisectCandidates(candidates []string, requires map[string][]string) []string {
+	sorted := slices.Clone(candidates)
+	sort.Strings(sorted)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		return len(requires[a]) - len(requires[b])
+	})
+
+	result := make([]string, 0, len(sorted))
+	added := make(map[string]bool, len(sorted))
+	for len(result) < len(sorted) {
+		progress := false
+		for _, mod := range sorted {
+			if added[mod] {
+				continue
+			}
+			ready := true
+			for _, dependency := range requires[mod] {
+				if !added[dependency] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				result = append(result, mod)
+				added[mod] = true
+				progress = true
+			}
+		}
+		if !progress {
+			// Dependency cycle, the remaining mods are always enabled together anyway
+			for _, mod := range sorted {
+				if !added[mod] {
+					result = append(result, mod)
+					added[mod] = true
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,324 @@
+package ficsitcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type CachedArchive struct {
+	Item     string    `json:"item"`
+	Version  string    `json:"version"`
+	Target   string    `json:"target"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"lastUsed"`
+
+	fileName string
+}
+
+type CachePruneResult struct {
+	DryRun  bool            `json:"dryRun"`
+	Removed []CachedArchive `json:"removed"`
+	// Size is the total size of the removed archives
+	Size int64 `json:"size"`
+	Kept int   `json:"kept"`
+}
+
+// PruneCache removes the cached mod archives that no lockfile of any install references.
+// With dryRun, it only lists the archives that would be removed
+func (f *ficsitCLI) PruneCache(dryRun bool) (*CachePruneResult, error) {
+	var result *CachePruneResult
+	err := f.action(ActionPruneCache, noItem, func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		defer close(taskChannel)
+
+		referenced := f.getReferencedCacheKeys()
+
+		archives, err := getCachedArchives()
+		if err != nil {
+			l.Error("failed to list cached archives", slog.Any("error", err))
+			return fmt.Errorf("failed to list cached archives: %w", err)
+		}
+
+		result = &CachePruneResult{
+			DryRun:  dryRun,
+			Removed: []CachedArchive{},
+		}
+		for i, archive := range archives {
+			taskChannel <- taskUpdate{
+				taskName: "prune",
+				progress: utils.Progress{
+					Current: int64(i + 1),
+					Total:   int64(len(archives)),
+				},
+			}
+
+			if referenced.has(archive) {
+				result.Kept++
+				continue
+			}
+			if !dryRun {
+				err := os.Remove(filepath.Join(downloadCacheDir(), archive.fileName))
+				if err != nil {
+					l.Error("failed to remove cached archive", slog.String("file", archive.fileName), slog.Any("error", err))
+					return fmt.Errorf("failed to remove cached archive %s: %w", archive.fileName, err)
+				}
+			}
+			result.Removed = append(result.Removed, archive)
+			result.Size += archive.Size
+		}
+
+		if !dryRun && len(result.Removed) > 0 {
+			reloadCacheIndex(l)
+		}
+
+		l.Info("pruned cache", slog.Bool("dryRun", dryRun), slog.Int("removed", len(result.Removed)), slog.Int64("size", result.Size))
+		return nil
+	})
+	return result, err
+}
+
+// evictCache removes the least recently used unreferenced archives until the cache fits the configured size.
+// Referenced archives are never removed, even if they alone exceed it
+func (f *ficsitCLI) evictCache(l *slog.Logger) {
+	maxSize := settings.Settings.MaxCacheSize
+	if maxSize <= 0 {
+		return
+	}
+
+	referenced := f.getReferencedCacheKeys()
+
+	archives, err := getCachedArchives()
+	if err != nil {
+		l.Warn("failed to list cached archives, skipping cache eviction", slog.Any("error", err))
+		return
+	}
+
+	// ficsit-cli does not touch the cached archives when reusing them,
+	// so the modification time of the referenced archives is updated here to track their last use
+	now := time.Now()
+	var totalSize int64
+	for i, archive := range archives {
+		totalSize += archive.Size
+		if referenced.has(archive) {
+			err := os.Chtimes(filepath.Join(downloadCacheDir(), archive.fileName), now, now)
+			if err != nil {
+				l.Warn("failed to update cached archive time", slog.String("file", archive.fileName), slog.Any("error", err))
+				continue
+			}
+			archives[i].LastUsed = now
+		}
+	}
+
+	if totalSize <= maxSize {
+		return
+	}
+
+	slices.SortFunc(archives, func(a, b CachedArchive) int {
+		return a.LastUsed.Compare(b.LastUsed)
+	})
+
+	evicted := false
+	for _, archive := range archives {
+		if totalSize <= maxSize {
+			break
+		}
+		if referenced.has(archive) {
+			continue
+		}
+		err := os.Remove(filepath.Join(downloadCacheDir(), archive.fileName))
+		if err != nil {
+			l.Warn("failed to evict cached archive", slog.String("file", archive.fileName), slog.Any("error", err))
+			continue
+		}
+		l.Info("evicted cached archive", slog.String("file", archive.fileName), slog.Int64("size", archive.Size))
+		totalSize -= archive.Size
+		evicted = true
+	}
+
+	if evicted {
+		reloadCacheIndex(l)
+	}
+
+	if totalSize > maxSize {
+		l.Info("cache is still larger than the maximum size, all remaining archives are in use", slog.Int64("size", totalSize), slog.Int64("max", maxSize))
+	}
+}
+
+// reloadCacheIndex makes ficsit-cli forget the removed archives,
+// otherwise the offline provider keeps offering versions that can no longer be installed
+func reloadCacheIndex(l *slog.Logger) {
+	_, err := ficsitcache.LoadCacheMods()
+	if err != nil {
+		l.Warn("failed to reload cache", slog.Any("error", err))
+	}
+}
+
+// cacheReferences are the archives that must be kept in the cache
+type cacheReferences struct {
+	// keys are the cache file names of the archives in use
+	keys map[string]bool
+	// mods are the mod references of unavailable installs whose lockfiles were never read,
+	// every archive of these mods is kept since the versions in use are unknown
+	mods map[string]bool
+}
+
+func (r cacheReferences) has(archive CachedArchive) bool {
+	return r.keys[archive.fileName] || r.mods[archive.Item]
+}
+
+// installCacheKeys keeps the archives last read from the lockfiles of each install,
+// so that the archives of an install that becomes unavailable are still known
+type installCacheKeys struct {
+	keys map[string]map[string]bool
+	lock sync.Mutex
+}
+
+// getReferencedCacheKeys returns the archives used by the lockfiles of every profile in every install, and by the local mods.
+// SMM keeps no lockfiles outside of the installs, the copies it makes (bisect, imported profiles)
+// are written next to the other lockfiles of the install, so they are found the same way.
+// For installs that cannot be read, including remote servers that are down or locked, the archives read last time are used,
+// or if there are none, every archive of the mods of their profile
+func (f *ficsitCLI) getReferencedCacheKeys() cacheReferences {
+	references := cacheReferences{
+		keys: make(map[string]bool),
+		mods: make(map[string]bool),
+	}
+
+	f.installCacheKeys.lock.Lock()
+	defer f.installCacheKeys.lock.Unlock()
+	if f.installCacheKeys.keys == nil {
+		f.installCacheKeys.keys = make(map[string]map[string]bool)
+	}
+
+	for _, installPath := range f.GetInstallations() {
+		installation := f.GetInstallation(installPath)
+		keys, err := f.readInstallCacheKeys(installation)
+		if err == nil {
+			f.installCacheKeys.keys[installPath] = keys
+		} else {
+			slog.Warn("failed to read install lockfiles, keeping its last known archives", slog.String("install", installPath), slog.Any("error", err))
+			var ok bool
+			keys, ok = f.installCacheKeys.keys[installPath]
+			if !ok {
+				if profile := f.GetProfile(installation.Profile); profile != nil {
+					for modReference := range profile.Mods {
+						references.mods[modReference] = true
+					}
+				}
+			}
+		}
+		for key := range keys {
+			references.keys[key] = true
+		}
+	}
+
+	// Local mods cannot be downloaded again if removed
+	for _, localMod := range f.GetLocalMods() {
+		for _, target := range localMod.Targets {
+			references.keys[target.CacheKey] = true
+		}
+	}
+
+	return references
+}
+
+// readInstallCacheKeys returns the cache file names of the archives used by the lockfiles of every profile of the install
+func (f *ficsitCLI) readInstallCacheKeys(installation *cli.Installation) (map[string]bool, error) {
+	meta, ok := f.installationMetadata.Load(installation.Path)
+	if !ok || meta.State != InstallStateValid {
+		return nil, fmt.Errorf("installation is not available")
+	}
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	keys := make(map[string]bool)
+	// Every profile used on the install has its own lockfile, same as in profileLockfilePath
+	modsDirectory := filepath.Join(installation.BasePath(), "FactoryGame", "Mods")
+	exists, err := d.Exists(modsDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mods directory: %w", err)
+	}
+	if !exists {
+		return keys, nil
+	}
+	entries, err := d.ReadDir(modsDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mods directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "-lock.json") {
+			continue
+		}
+		lockfileBytes, err := d.Read(filepath.Join(modsDirectory, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read lockfile %s: %w", entry.Name(), err)
+		}
+		var lockfile resolver.LockFile
+		err = json.Unmarshal(lockfileBytes, &lockfile)
+		if err != nil {
+			slog.Warn("ignoring invalid lockfile", slog.String("install", installation.Path), slog.String("lockfile", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		for modReference, lockedMod := range lockfile.Mods {
+			for target := range lockedMod.Targets {
+				keys[modReference+"_"+lockedMod.Version+"_"+target+".zip"] = true
+			}
+		}
+	}
+	return keys, nil
+}
+
+func downloadCacheDir() string {
+	return filepath.Join(viper.GetString("cache-dir"), "downloadCache")
+}
+
+func getCachedArchives() ([]CachedArchive, error) {
+	entries, err := os.ReadDir(downloadCacheDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CachedArchive{}, nil
+		}
+		return nil, fmt.Errorf("failed to read download cache: %w", err)
+	}
+
+	archives := make([]CachedArchive, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		modReference, version, target, ok := parseCacheKey(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+		archives = append(archives, CachedArchive{
+			Item:     modReference,
+			Version:  version,
+			Target:   target,
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+			fileName: entry.Name(),
+		})
+	}
+	return archives, nil
+}
@@ -0,0 +1,313 @@
+package ficsitcli
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+// catalogFile is the metadata of a single archive in the download cache.
+// Size and ModTime are used to detect when the archive changed and must be read again
+type catalogFile struct {
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"modTime"`
+	ModReference string    `json:"modReference"`
+	Name         string    `json:"name"`
+	Author       string    `json:"author"`
+	Version      string    `json:"version"`
+	Icon         *string   `json:"icon,omitempty"`
+}
+
+type offlineCatalog struct {
+	Files map[string]catalogFile `json:"files"`
+
+	mods []Mod
+}
+
+var (
+	catalog     *offlineCatalog
+	catalogLock sync.Mutex
+)
+
+const catalogFileName = "offlineCatalog.json"
+
+type OfflineModsQuery struct {
+	// Search matches the name, reference and authors, case insensitive
+	Search string `json:"search"`
+	// Order is one of name, reference, author or version. Defaults to name
+	Order      string `json:"order"`
+	Descending bool   `json:"descending"`
+	Offset     int    `json:"offset"`
+	// Limit of 0 returns all the remaining mods
+	Limit int `json:"limit"`
+}
+
+type OfflineModsPage struct {
+	Mods  []Mod `json:"mods"`
+	Total int   `json:"total"`
+}
+
+// OfflineSearchMods lists the cached mods without their versions, which are only loaded by OfflineGetMod
+func (f *ficsitCLI) OfflineSearchMods(query OfflineModsQuery) (*OfflineModsPage, error) {
+	mods, err := getOfflineCatalogMods()
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.ToLower(strings.TrimSpace(query.Search))
+	if search != "" {
+		mods = slices.DeleteFunc(mods, func(mod Mod) bool {
+			if strings.Contains(strings.ToLower(mod.Name), search) || strings.Contains(strings.ToLower(mod.ModReference), search) {
+				return false
+			}
+			return !slices.ContainsFunc(mod.Authors, func(author string) bool {
+				return strings.Contains(strings.ToLower(author), search)
+			})
+		})
+	}
+
+	var compare func(a, b Mod) int
+	switch query.Order {
+	case "", "name":
+		compare = func(a, b Mod) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case "reference":
+		compare = func(a, b Mod) int {
+			return strings.Compare(strings.ToLower(a.ModReference), strings.ToLower(b.ModReference))
+		}
+	case "author":
+		compare = func(a, b Mod) int {
+			return strings.Compare(strings.ToLower(strings.Join(a.Authors, ",")), strings.ToLower(strings.Join(b.Authors, ",")))
+		}
+	case "version":
+		compare = func(a, b Mod) int {
+			return compareVersions(a.LatestVersion, b.LatestVersion)
+		}
+	default:
+		return nil, fmt.Errorf("unknown order %s", query.Order)
+	}
+	slices.SortStableFunc(mods, func(a, b Mod) int {
+		result := compare(a, b)
+		if result == 0 {
+			result = strings.Compare(a.ModReference, b.ModReference)
+		}
+		if query.Descending {
+			return -result
+		}
+		return result
+	})
+
+	page := &OfflineModsPage{
+		Total: len(mods),
+	}
+	low := min(max(query.Offset, 0), len(mods))
+	high := len(mods)
+	if query.Limit > 0 {
+		high = min(low+query.Limit, len(mods))
+	}
+	page.Mods = mods[low:high]
+
+	return page, nil
+}
+
+// getOfflineCatalogMods returns a copy of the catalog mods, after updating the catalog with the changes in the download cache
+func getOfflineCatalogMods() ([]Mod, error) {
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+
+	if catalog == nil {
+		catalog = loadOfflineCatalog()
+	}
+
+	changed, err := catalog.refresh()
+	if err != nil {
+		return nil, err
+	}
+	if changed || catalog.mods == nil {
+		catalog.mods = catalog.buildMods()
+	}
+	if changed {
+		err := catalog.save()
+		if err != nil {
+			slog.Warn("failed to save offline catalog", slog.Any("error", err))
+		}
+	}
+
+	return slices.Clone(catalog.mods), nil
+}
+
+func loadOfflineCatalog() *offlineCatalog {
+	c := &offlineCatalog{
+		Files: make(map[string]catalogFile),
+	}
+	catalogBytes, err := os.ReadFile(filepath.Join(viper.GetString("smm-cache-dir"), catalogFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read offline catalog, rebuilding it", slog.Any("error", err))
+		}
+		return c
+	}
+	err = json.Unmarshal(catalogBytes, c)
+	if err != nil || c.Files == nil {
+		slog.Warn("failed to parse offline catalog, rebuilding it", slog.Any("error", err))
+		c.Files = make(map[string]catalogFile)
+	}
+	return c
+}
+
+func (c *offlineCatalog) save() error {
+	catalogBytes, err := utils.JSONMarshal(c, 0)
+	if err != nil {
+		return fmt.Errorf("failed to marshal offline catalog: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(viper.GetString("smm-cache-dir"), catalogFileName), catalogBytes, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write offline catalog: %w", err)
+	}
+	return nil
+}
+
+// refresh reads only the archives that were added or changed since the last refresh, and drops the removed ones
+func (c *offlineCatalog) refresh() (bool, error) {
+	entries, err := os.ReadDir(downloadCacheDir())
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read download cache: %w", err)
+	}
+
+	changed := false
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zip") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			slog.Warn("failed to stat cached archive", slog.String("file", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		present[entry.Name()] = true
+
+		existing, ok := c.Files[entry.Name()]
+		if ok && existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		file, err := readCatalogFile(filepath.Join(downloadCacheDir(), entry.Name()))
+		if err != nil {
+			// Keep the file with no mod reference, so it is not read again until it changes
+			slog.Warn("failed to read cached archive", slog.String("file", entry.Name()), slog.Any("error", err))
+			file = &catalogFile{}
+		}
+		file.Size = info.Size()
+		file.ModTime = info.ModTime()
+		c.Files[entry.Name()] = *file
+		changed = true
+	}
+
+	for name := range c.Files {
+		if !present[name] {
+			delete(c.Files, name)
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// buildMods groups the archives by mod, using the metadata of the latest version, same as ficsitcache.LoadCacheMods
+func (c *offlineCatalog) buildMods() []Mod {
+	latest := make(map[string]catalogFile)
+	for _, file := range c.Files {
+		if file.ModReference == "" {
+			continue
+		}
+		existing, ok := latest[file.ModReference]
+		if !ok || compareVersions(file.Version, existing.Version) > 0 {
+			latest[file.ModReference] = file
+		}
+	}
+
+	mods := make([]Mod, 0, len(latest))
+	for modReference, file := range latest {
+		authors := make([]string, 0)
+		for _, author := range strings.Split(file.Author, ",") {
+			authors = append(authors, strings.TrimSpace(author))
+		}
+		mods = append(mods, Mod{
+			ModReference:  modReference,
+			Name:          file.Name,
+			Logo:          file.Icon,
+			Authors:       authors,
+			LatestVersion: file.Version,
+			Versions:      []resolver.ModVersion{},
+		})
+	}
+	return mods
+}
+
+func readCatalogFile(path string) (*catalogFile, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	var upluginFile *zip.File
+	var iconFile *zip.File
+	for _, file := range archive.File {
+		if upluginFile == nil && strings.HasSuffix(file.Name, ".uplugin") {
+			upluginFile = file
+		}
+		if file.Name == ficsitcache.IconFilename {
+			iconFile = file
+		}
+	}
+	if upluginFile == nil {
+		return nil, errors.New("no uplugin file found in archive")
+	}
+
+	uplugin, err := readUPlugin(upluginFile)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &catalogFile{
+		ModReference: strings.TrimSuffix(upluginFile.Name, ".uplugin"),
+		Name:         uplugin.FriendlyName,
+		Author:       uplugin.CreatedBy,
+		Version:      uplugin.SemVersion,
+	}
+
+	if iconFile != nil {
+		iconReader, err := iconFile.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open icon: %w", err)
+		}
+		defer iconReader.Close()
+		iconBytes, err := io.ReadAll(iconReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read icon: %w", err)
+		}
+		icon := base64.StdEncoding.EncodeToString(iconBytes)
+		result.Icon = &icon
+	}
+
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package ficsitcli
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type ConnectivityState struct {
+	// OfflinePreference is the offline mode chosen by the user
+	OfflinePreference bool `json:"offlinePreference"`
+	// Offline is the mode actually in use, which is also offline while the API is unreachable
+	Offline      bool       `json:"offline"`
+	APIReachable bool       `json:"apiReachable"`
+	LastCheck    *time.Time `json:"lastCheck"`
+}
+
+type connectivity struct {
+	apiUnreachable bool
+	lastCheck      *time.Time
+	lock           sync.Mutex
+}
+
+const connectivityProbeInterval = 30 * time.Second
+
+func (f *ficsitCLI) GetConnectivityState() ConnectivityState {
+	f.connectivity.lock.Lock()
+	defer f.connectivity.lock.Unlock()
+	return f.connectivityStateLocked()
+}
+
+func (f *ficsitCLI) connectivityStateLocked() ConnectivityState {
+	return ConnectivityState{
+		OfflinePreference: settings.Settings.Offline,
+		Offline:           settings.Settings.Offline || f.connectivity.apiUnreachable,
+		APIReachable:      !f.connectivity.apiUnreachable,
+		LastCheck:         f.connectivity.lastCheck,
+	}
+}
+
+// updateEffectiveOffline must be called with the connectivity lock held
+func (f *ficsitCLI) updateEffectiveOffline() {
+	state := f.connectivityStateLocked()
+	f.localMods().SetOffline(state.Offline)
+	wailsRuntime.EventsEmit(appCommon.AppContext, "offline", state.Offline)
+	wailsRuntime.EventsEmit(appCommon.AppContext, "connectivity", state)
+}
+
+// markAPIUnreachable switches to offline mode until the background probe can reach the API again
+func (f *ficsitCLI) markAPIUnreachable(err error) {
+	f.connectivity.lock.Lock()
+	defer f.connectivity.lock.Unlock()
+	if f.connectivity.apiUnreachable {
+		return
+	}
+	slog.Warn("mod API is unreachable, switching to offline mode", slog.Any("error", err))
+	f.connectivity.apiUnreachable = true
+	f.updateEffectiveOffline()
+}
+
+func (f *ficsitCLI) markAPIReachable() {
+	f.connectivity.lock.Lock()
+	defer f.connectivity.lock.Unlock()
+	if !f.connectivity.apiUnreachable {
+		return
+	}
+	slog.Info("mod API is reachable again")
+	f.connectivity.apiUnreachable = false
+	f.updateEffectiveOffline()
+}
+
+// StartConnectivityWatcher checks the API on startup, and then keeps probing it while it is unreachable
+func (f *ficsitCLI) StartConnectivityWatcher() {
+	go func() {
+		if !settings.Settings.Offline {
+			f.probeAPI()
+		}
+
+		ticker := time.NewTicker(connectivityProbeInterval)
+		for range ticker.C {
+			f.connectivity.lock.Lock()
+			unreachable := f.connectivity.apiUnreachable
+			f.connectivity.lock.Unlock()
+
+			// While the user chose offline mode, the API state does not matter
+			if unreachable && !settings.Settings.Offline {
+				f.probeAPI()
+			}
+		}
+	}()
+}
+
+func (f *ficsitCLI) probeAPI() {
+	p := newRepositoryProvider(nil)
+	reachable := false
+	var lastError string
+	for _, endpoint := range settings.Settings.GetRepositoryEndpoints() {
+		health := p.checkEndpoint(endpoint)
+		if health.Healthy {
+			reachable = true
+			break
+		}
+		lastError = health.Error
+	}
+
+	now := time.Now()
+	f.connectivity.lock.Lock()
+	f.connectivity.lastCheck = &now
+	f.connectivity.lock.Unlock()
+
+	if reachable {
+		f.markAPIReachable()
+	} else {
+		f.markAPIUnreachable(errors.New(lastError))
+	}
+}
@@ -0,0 +1,456 @@
+package ficsitcli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/credentials"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type CredentialStoreState struct {
+	Locked              bool `json:"locked"`
+	PassphraseProtected bool `json:"passphraseProtected"`
+	// ObfuscatedOnly is set when the credentials are readable by anything that can read the SMM files,
+	// because there is no passphrase and the OS does not protect the key
+	ObfuscatedOnly bool `json:"obfuscatedOnly"`
+}
+
+// remoteDisk connects to a remote install using the credentials from the store,
+// so that they never have to be part of the install path that ficsit-cli saves.
+// The connection is created on first use, and again after the credentials change if it never worked.
+// ficsit-cli disks cannot be closed, so a disk that is connected is kept instead of leaking its connections
+type remoteDisk struct {
+	f     *ficsitCLI
+	path  string
+	inner disk.Disk
+	// connected is set once the inner disk is logged in
+	connected bool
+	lock      sync.Mutex
+}
+
+func (d *remoteDisk) get() (disk.Disk, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.inner != nil {
+		return d.inner, nil
+	}
+	fullPath, err := d.f.withCredentials(d.path)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := disk.FromPath(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	d.inner = inner
+	// The SFTP disk logs in when created, while the FTP one only connects on use
+	if parsed, err := url.Parse(fullPath); err == nil && parsed.Scheme == "sftp" {
+		d.connected = true
+	}
+	return inner, nil
+}
+
+// done marks the disk as connected once an operation succeeded, and returns err
+func (d *remoteDisk) done(err error) error {
+	if err == nil {
+		d.lock.Lock()
+		d.connected = true
+		d.lock.Unlock()
+	}
+	return err
+}
+
+// reset makes the next operation connect with the current credentials.
+// Returns false if the disk is already connected, in which case it keeps using its connections,
+// and the new credentials are used the next time SMM starts
+func (d *remoteDisk) reset() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.connected {
+		return false
+	}
+	d.inner = nil
+	return true
+}
+
+func (d *remoteDisk) Exists(path string) (bool, error) {
+	inner, err := d.get()
+	if err != nil {
+		return false, err
+	}
+	exists, err := inner.Exists(path)
+	return exists, d.done(err)
+}
+
+func (d *remoteDisk) Read(path string) ([]byte, error) {
+	inner, err := d.get()
+	if err != nil {
+		return nil, err
+	}
+	data, err := inner.Read(path)
+	return data, d.done(err)
+}
+
+func (d *remoteDisk) Write(path string, data []byte) error {
+	inner, err := d.get()
+	if err != nil {
+		return err
+	}
+	return d.done(inner.Write(path, data))
+}
+
+func (d *remoteDisk) Remove(path string) error {
+	inner, err := d.get()
+	if err != nil {
+		return err
+	}
+	return d.done(inner.Remove(path))
+}
+
+func (d *remoteDisk) MkDir(path string) error {
+	inner, err := d.get()
+	if err != nil {
+		return err
+	}
+	return d.done(inner.MkDir(path))
+}
+
+func (d *remoteDisk) ReadDir(path string) ([]disk.Entry, error) {
+	inner, err := d.get()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := inner.ReadDir(path)
+	return entries, d.done(err)
+}
+
+func (d *remoteDisk) Open(path string, flag int) (io.WriteCloser, error) {
+	inner, err := d.get()
+	if err != nil {
+		return nil, err
+	}
+	writer, err := inner.Open(path, flag)
+	return writer, d.done(err)
+}
+
+// splitCredentials removes the username and password from a remote path
+func splitCredentials(path string) (string, *credentials.Credential, error) {
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse path: %w", err)
+	}
+	if parsed.User == nil {
+		return path, nil, nil
+	}
+	password, _ := parsed.User.Password()
+	credential := &credentials.Credential{
+		Username: parsed.User.Username(),
+		Password: password,
+	}
+	parsed.User = nil
+	return parsed.String(), credential, nil
+}
+
+func joinCredentials(path string, credential credentials.Credential) (string, error) {
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse path: %w", err)
+	}
+	if credential.Password != "" {
+		parsed.User = url.UserPassword(credential.Username, credential.Password)
+	} else {
+		parsed.User = url.User(credential.Username)
+	}
+	return parsed.String(), nil
+}
+
+// withCredentials returns the path to pass to disk.FromPath for a remote install.
+// Paths that still contain credentials, because they could not be migrated yet, are used as they are
+func (f *ficsitCLI) withCredentials(path string) (string, error) {
+	_, credential, err := splitCredentials(path)
+	if err != nil {
+		return "", err
+	}
+	if credential != nil {
+		return path, nil
+	}
+	stored, ok, err := f.credentials.Get(remoteKey(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+	if !ok {
+		return path, nil
+	}
+	return joinCredentials(path, stored)
+}
+
+// attachRemoteDisks makes the remote installs connect using the stored credentials
+func (f *ficsitCLI) attachRemoteDisks() {
+	for _, installation := range f.ficsitCli.Installations.Installations {
+		local, err := isLocal(installation.Path)
+		if err != nil || local {
+			continue
+		}
+		if d, ok := installation.DiskInstance.(*remoteDisk); ok {
+			d.reset()
+			continue
+		}
+		installation.DiskInstance = &remoteDisk{f: f, path: installation.Path}
+	}
+}
+
+// migrateRemoteCredentials moves the credentials of remote installs added by previous versions
+// from their path to the credential store
+func (f *ficsitCLI) migrateRemoteCredentials() {
+	if f.credentials.Locked() {
+		return
+	}
+
+	migrated := false
+	for _, installation := range f.ficsitCli.Installations.Installations {
+		local, err := isLocal(installation.Path)
+		if err != nil || local {
+			continue
+		}
+		cleanPath, credential, err := splitCredentials(installation.Path)
+		if err != nil || credential == nil {
+			continue
+		}
+		l := slog.With(slog.String("task", "migrateRemoteCredentials"), slog.String("install", installation.Path))
+		if f.ficsitCli.Installations.GetInstallation(cleanPath) != nil {
+			l.Warn("an install with the same path without credentials already exists, keeping the credentials in the path")
+			continue
+		}
+		err = f.credentials.Set(remoteKey(cleanPath), *credential)
+		if err != nil {
+			l.Error("failed to store credentials", slog.Any("error", err))
+			continue
+		}
+		f.renameInstall(l, installation, cleanPath)
+		migrated = true
+	}
+
+	if !migrated {
+		return
+	}
+	err := f.ficsitCli.Installations.Save()
+	if err != nil {
+		slog.Error("failed to save installations", slog.Any("error", err))
+	}
+	_ = settings.SaveSettings()
+}
+
+// renameInstall changes the path of an install, moving everything SMM keeps for it to the new path
+func (f *ficsitCLI) renameInstall(l *slog.Logger, installation *cli.Installation, newPath string) {
+	oldPath := installation.Path
+	oldKey := remoteKey(oldPath)
+	newKey := remoteKey(newPath)
+
+	installation.Path = newPath
+	if d, ok := installation.DiskInstance.(*remoteDisk); ok {
+		d.lock.Lock()
+		d.path = newPath
+		d.lock.Unlock()
+	}
+	if f.ficsitCli.Installations.SelectedInstallation == oldPath {
+		f.ficsitCli.Installations.SelectedInstallation = newPath
+	}
+
+	if name, ok := settings.Settings.RemoteNames[oldKey]; ok {
+		settings.Settings.RemoteNames[newKey] = name
+		delete(settings.Settings.RemoteNames, oldKey)
+	}
+	if options, ok := settings.Settings.InstallLaunchOptions[oldKey]; ok {
+		settings.Settings.InstallLaunchOptions[newKey] = options
+		delete(settings.Settings.InstallLaunchOptions, oldKey)
+	}
+
+	saveProfilesLock.Lock()
+	data := loadSaveProfiles()
+	if install, ok := data.Installs[oldKey]; ok {
+		data.Installs[newKey] = install
+		delete(data.Installs, oldKey)
+		err := data.save()
+		if err != nil {
+			l.Error("failed to move save profiles", slog.Any("error", err))
+		}
+	}
+	saveProfilesLock.Unlock()
+
+	for _, dir := range []string{"profileConfigs", "saveBackups"} {
+		oldDir := filepath.Join(viper.GetString("smm-local-dir"), dir, oldKey)
+		err := os.Rename(oldDir, filepath.Join(viper.GetString("smm-local-dir"), dir, newKey))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			l.Error("failed to move install data", slog.String("dir", dir), slog.Any("error", err))
+		}
+	}
+
+	if meta, ok := f.installationMetadata.LoadAndDelete(oldPath); ok {
+		if meta.Info != nil {
+			info := *meta.Info
+			info.Path = newPath
+			meta.Info = &info
+		}
+		f.installationMetadata.Store(newPath, meta)
+	}
+	f.remoteHealth.lock.Lock()
+	delete(f.remoteHealth.servers, oldPath)
+	f.remoteHealth.lock.Unlock()
+}
+
+func (f *ficsitCLI) GetCredentialStoreState() CredentialStoreState {
+	passphraseProtected := f.credentials.PassphraseProtected()
+	return CredentialStoreState{
+		Locked:              f.credentials.Locked(),
+		PassphraseProtected: passphraseProtected,
+		ObfuscatedOnly:      !passphraseProtected && !credentials.SystemProtectedKey,
+	}
+}
+
+// UnlockCredentialStore unlocks a passphrase protected credential store, then loads the remote installs that needed it
+func (f *ficsitCLI) UnlockCredentialStore(passphrase string) error {
+	err := f.credentials.Unlock(passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to unlock credential store: %w", err)
+	}
+
+	f.migrateRemoteCredentials()
+	f.attachRemoteDisks()
+	f.EmitGlobals()
+
+	go f.initRemoteServerInstallationsMetadata()
+	return nil
+}
+
+// ResetCredentialStore deletes every stored credential, for when the store cannot be unlocked anymore.
+// The remote installs that used them need their credentials to be set again
+func (f *ficsitCLI) ResetCredentialStore() error {
+	err := f.credentials.Reset()
+	if err != nil {
+		slog.Error("failed to reset credential store", slog.Any("error", err))
+		return fmt.Errorf("failed to reset credential store: %w", err)
+	}
+
+	f.attachRemoteDisks()
+	f.EmitGlobals()
+
+	go f.initRemoteServerInstallationsMetadata()
+	return nil
+}
+
+// SetCredentialStorePassphrase protects the stored credentials with a passphrase. An empty passphrase removes the protection
+func (f *ficsitCLI) SetCredentialStorePassphrase(passphrase string) error {
+	err := f.credentials.SetPassphrase(passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to set passphrase: %w", err)
+	}
+	return nil
+}
+
+func (f *ficsitCLI) GetRemoteServerUsername(path string) (string, error) {
+	credential, _, err := f.credentials.Get(remoteKey(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+	return credential.Username, nil
+}
+
+// SetRemoteServerCredentials changes the credentials used to connect to a remote install.
+// The new credentials are only saved if they can be used to connect
+func (f *ficsitCLI) SetRemoteServerCredentials(path string, username string, password string) error {
+	installation := f.GetInstallation(path)
+	if installation == nil {
+		return fmt.Errorf("installation %s not found", path)
+	}
+	remote, ok := installation.DiskInstance.(*remoteDisk)
+	if !ok {
+		return fmt.Errorf("installation %s is not remote", path)
+	}
+	if f.credentials.Locked() {
+		return credentials.ErrLocked
+	}
+	_, pathCredential, err := splitCredentials(path)
+	if err != nil {
+		return err
+	}
+	if pathCredential != nil {
+		return fmt.Errorf("the credentials of this installation are part of its path, remove and add it again instead")
+	}
+
+	l := slog.With(slog.String("task", "setRemoteServerCredentials"), slog.String("install", path))
+
+	credential := credentials.Credential{Username: username, Password: password}
+	fullPath, err := joinCredentials(path, credential)
+	if err != nil {
+		return err
+	}
+	err = testRemoteCredentials(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	err = f.credentials.Set(remoteKey(path), credential)
+	if err != nil {
+		l.Error("failed to store credentials", slog.Any("error", err))
+		return fmt.Errorf("failed to store credentials: %w", err)
+	}
+	if !remote.reset() {
+		l.Info("server is already connected, the new credentials will be used after a restart")
+	}
+
+	go f.checkRemoteServer(installation)
+	return nil
+}
+
+// testRemoteCredentials logs into the server over a separate connection, closed once done,
+// since the ficsit-cli disks keep their connections open
+func testRemoteCredentials(fullPath string) error {
+	parsed, err := url.Parse(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse path: %w", err)
+	}
+	switch parsed.Scheme {
+	case "ftp":
+		conn, err := loginFTP(parsed)
+		if err != nil {
+			return err
+		}
+		return conn.Quit() //nolint:wrapcheck
+	case "sftp":
+		var auth []ssh.AuthMethod
+		if password, ok := parsed.User.Password(); ok {
+			auth = append(auth, ssh.Password(password))
+		}
+		conn, err := ssh.Dial("tcp", parsed.Host, &ssh.ClientConfig{
+			User: parsed.User.Username(),
+			Auth: auth,
+			// Same as ficsit-cli, which does not check host keys either
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(), //nolint:gosec
+			Timeout:         remoteDialTimeout,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to connect to ssh server: %w", err)
+		}
+		defer conn.Close()
+		client, err := sftp.NewClient(conn)
+		if err != nil {
+			return fmt.Errorf("failed to create sftp client: %w", err)
+		}
+		return client.Close() //nolint:wrapcheck
+	default:
+		return fmt.Errorf("unsupported scheme %s", parsed.Scheme)
+	}
+}
@@ -0,0 +1,287 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+)
+
+type ModDiskUsage struct {
+	Item    string `json:"item"`
+	Version string `json:"version"`
+	// Target is only known for cached archives
+	Target string `json:"target,omitempty"`
+	Size   int64  `json:"size"`
+	Files  int    `json:"files"`
+}
+
+type InstallDiskUsage struct {
+	Install string         `json:"install"`
+	Size    int64          `json:"size"`
+	Mods    []ModDiskUsage `json:"mods"`
+	Error   *string        `json:"error"`
+}
+
+type CacheDiskUsage struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// Mods are the cached mod archives
+	Mods []ModDiskUsage `json:"mods"`
+	// Other is the size of everything else in the cache, such as the mod metadata and icons
+	Other int64 `json:"other"`
+}
+
+type DiskUsageReport struct {
+	Installs []InstallDiskUsage `json:"installs"`
+	Cache    *CacheDiskUsage    `json:"cache"`
+}
+
+type DiskUsageProgress struct {
+	Scope   string `json:"scope"` // install path, or "cache"
+	Item    string `json:"item"`
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+}
+
+// GetDiskUsage scans every valid install and the cache directory, emitting diskUsageProgress events while scanning
+func (f *ficsitCLI) GetDiskUsage() (*DiskUsageReport, error) {
+	report := &DiskUsageReport{
+		Installs: []InstallDiskUsage{},
+	}
+
+	installs := f.GetInstallations()
+	results := make([]*InstallDiskUsage, len(installs))
+	var wg sync.WaitGroup
+	for i, installPath := range installs {
+		meta, ok := f.installationMetadata.Load(installPath)
+		if !ok || meta.State != InstallStateValid {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = f.getInstallDiskUsage(installPath)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result != nil {
+			report.Installs = append(report.Installs, *result)
+		}
+	}
+
+	cacheUsage, err := getCacheDiskUsage()
+	if err != nil {
+		return nil, err
+	}
+	report.Cache = cacheUsage
+
+	return report, nil
+}
+
+func (f *ficsitCLI) GetInstallDiskUsage(path string) (*InstallDiskUsage, error) {
+	if f.GetInstallation(path) == nil {
+		return nil, fmt.Errorf("installation %s not found", path)
+	}
+	return f.getInstallDiskUsage(path), nil
+}
+
+func (f *ficsitCLI) GetCacheDiskUsage() (*CacheDiskUsage, error) {
+	return getCacheDiskUsage()
+}
+
+// getInstallDiskUsage reports the failure in the result instead of returning it,
+// so one unreachable remote server does not fail the whole report
+func (f *ficsitCLI) getInstallDiskUsage(path string) *InstallDiskUsage {
+	l := slog.With(slog.String("task", "getInstallDiskUsage"), slog.String("install", path))
+
+	result := &InstallDiskUsage{
+		Install: path,
+		Mods:    []ModDiskUsage{},
+	}
+	setError := func(err error) *InstallDiskUsage {
+		l.Error("failed to get disk usage", slog.Any("error", err))
+		errString := err.Error()
+		result.Error = &errString
+		return result
+	}
+
+	installation := f.GetInstallation(path)
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		return setError(fmt.Errorf("failed to get disk: %w", err))
+	}
+
+	// Mods that are not in the lockfile are still reported, without a version
+	versions := make(map[string]string)
+	lockfile, err := installation.LockFile(f.ficsitCli)
+	if err != nil {
+		l.Warn("failed to read lockfile", slog.Any("error", err))
+	} else if lockfile != nil {
+		for modReference, lockedMod := range lockfile.Mods {
+			versions[modReference] = lockedMod.Version
+		}
+	}
+
+	modsDirectory := filepath.Join(installation.BasePath(), "FactoryGame", "Mods")
+	exists, err := d.Exists(modsDirectory)
+	if err != nil {
+		return setError(fmt.Errorf("failed to check if mods directory exists: %w", err))
+	}
+	if !exists {
+		return result
+	}
+
+	entries, err := d.ReadDir(modsDirectory)
+	if err != nil {
+		return setError(fmt.Errorf("failed to read mods directory: %w", err))
+	}
+
+	infos, err := f.listFileInfos(installation, d, modsDirectory)
+	if err != nil {
+		return setError(fmt.Errorf("failed to list mod files: %w", err))
+	}
+
+	for i, entry := range entries {
+		if !entry.IsDir() {
+			result.Size += infos[entry.Name()].Size
+			continue
+		}
+
+		var size int64
+		var files int
+		for name, info := range infos {
+			if strings.HasPrefix(name, entry.Name()+"/") {
+				size += info.Size
+				files++
+			}
+		}
+		result.Mods = append(result.Mods, ModDiskUsage{
+			Item:    entry.Name(),
+			Version: versions[entry.Name()],
+			Size:    size,
+			Files:   files,
+		})
+		result.Size += size
+
+		wailsRuntime.EventsEmit(appCommon.AppContext, "diskUsageProgress", DiskUsageProgress{
+			Scope:   path,
+			Item:    entry.Name(),
+			Current: int64(i + 1),
+			Total:   int64(len(entries)),
+		})
+	}
+
+	sortModDiskUsage(result.Mods)
+
+	return result
+}
+
+func getCacheDiskUsage() (*CacheDiskUsage, error) {
+	cacheDir := viper.GetString("cache-dir")
+	downloadCache := filepath.Join(cacheDir, "downloadCache")
+
+	result := &CacheDiskUsage{
+		Path: cacheDir,
+		Mods: []ModDiskUsage{},
+	}
+
+	var scanned int64
+	err := filepath.WalkDir(cacheDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		result.Size += info.Size()
+
+		scanned++
+		if scanned%100 == 0 {
+			wailsRuntime.EventsEmit(appCommon.AppContext, "diskUsageProgress", DiskUsageProgress{
+				Scope:   "cache",
+				Item:    entry.Name(),
+				Current: scanned,
+			})
+		}
+
+		if filepath.Dir(path) != downloadCache {
+			result.Other += info.Size()
+			return nil
+		}
+		modReference, version, target, ok := parseCacheKey(entry.Name())
+		if !ok {
+			result.Other += info.Size()
+			return nil
+		}
+		result.Mods = append(result.Mods, ModDiskUsage{
+			Item:    modReference,
+			Version: version,
+			Target:  target,
+			Size:    info.Size(),
+			Files:   1,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan cache directory: %w", err)
+	}
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "diskUsageProgress", DiskUsageProgress{
+		Scope:   "cache",
+		Current: scanned,
+		Total:   scanned,
+	})
+
+	sortModDiskUsage(result.Mods)
+
+	return result, nil
+}
+
+// parseCacheKey splits the download cache file names used by ficsit-cli, <mod reference>_<version>_<target>.zip
+func parseCacheKey(name string) (string, string, string, bool) {
+	name, ok := strings.CutSuffix(name, ".zip")
+	if !ok {
+		return "", "", "", false
+	}
+	targetIdx := strings.LastIndex(name, "_")
+	if targetIdx == -1 {
+		return "", "", "", false
+	}
+	versionIdx := strings.LastIndex(name[:targetIdx], "_")
+	if versionIdx == -1 {
+		return "", "", "", false
+	}
+	return name[:versionIdx], name[versionIdx+1 : targetIdx], name[targetIdx+1:], true
+}
+
+func sortModDiskUsage(mods []ModDiskUsage) {
+	slices.SortFunc(mods, func(a, b ModDiskUsage) int {
+		if a.Item != b.Item {
+			return strings.Compare(a.Item, b.Item)
+		}
+		if a.Version != b.Version {
+			return compareVersions(a.Version, b.Version)
+		}
+		return strings.Compare(a.Target, b.Target)
+	})
+}
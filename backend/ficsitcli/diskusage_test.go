@@ -0,0 +1,32 @@
+package ficsitcli
+
+import "testing"
+
+func TestParseCacheKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		modReference string
+		version      string
+		target       string
+		ok           bool
+	}{
+		{"SML_3.8.0_Windows.zip", "SML", "3.8.0", "Windows", true},
+		{"RefinedPower_3.2.1-beta.1_LinuxServer.zip", "RefinedPower", "3.2.1-beta.1", "LinuxServer", true},
+		// Only the last two underscores separate the fields, mod references can contain underscores
+		{"Mod_With_Underscores_1.0.0_WindowsServer.zip", "Mod_With_Underscores", "1.0.0", "WindowsServer", true},
+		{"SML_3.8.0_Windows", "", "", "", false},
+		{"SML_3.8.0.zip", "", "", "", false},
+		{"SML.zip", "", "", "", false},
+		{"SML_3.8.0_Windows.zip.tmp", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modReference, version, target, ok := parseCacheKey(tt.name)
+			if modReference != tt.modReference || version != tt.version || target != tt.target || ok != tt.ok {
+				t.Errorf("parseCacheKey(%q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+					tt.name, modReference, version, target, ok, tt.modReference, tt.version, tt.target, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package ficsitcli
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+)
+
+// zipDiskDirectory writes all the files in dir to a zip, with paths relative to dir.
+// Files are read one at a time, so large directories are not held in memory
+func zipDiskDirectory(w io.Writer, d disk.Disk, dir string, comment string) error {
+	files, err := listDiskFiles(d, dir, "")
+	if err != nil {
+		return err
+	}
+
+	writer := zip.NewWriter(w)
+	err = writer.SetComment(comment)
+	if err != nil {
+		return fmt.Errorf("failed to set zip comment: %w", err)
+	}
+	for _, file := range files {
+		data, err := d.Read(filepath.Join(dir, file))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		fileWriter, err := writer.Create(file)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", file, err)
+		}
+		_, err = fileWriter.Write(data)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", file, err)
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to finish zip: %w", err)
+	}
+	return nil
+}
+
+// unzipToDisk writes the files of the zip into dir, overwriting existing files
+func unzipToDisk(reader *zip.Reader, d disk.Disk, dir string) error {
+	err := d.MkDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		// Do not allow the zip to write outside the directory
+		name := path.Clean("/" + file.Name)[1:]
+		if name == "" || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path %s", file.Name)
+		}
+
+		data, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+
+		outPath := filepath.Join(dir, filepath.FromSlash(name))
+		err = d.MkDir(filepath.Dir(outPath))
+		if err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		err = d.Write(outPath, data)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	return data, nil
+}
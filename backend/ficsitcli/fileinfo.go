@@ -0,0 +1,121 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+)
+
+type diskFileInfo struct {
+	Size    int64
+	ModTime time.Time
+}
+
+const remoteDialTimeout = 5 * time.Second
+
+// listFileInfos returns the size and modification time of every file under root, by path relative to root.
+// The entries of the ficsit-cli FTP disk do not expose them, so FTP installs are listed over a separate connection
+func (f *ficsitCLI) listFileInfos(installation *cli.Installation, d disk.Disk, root string) (map[string]diskFileInfo, error) {
+	parsed, err := url.Parse(installation.Path)
+	if err == nil && parsed.Scheme == "ftp" {
+		return f.listFTPFileInfos(installation.Path, root)
+	}
+
+	infos := make(map[string]diskFileInfo)
+	err = listDiskFileInfos(d, root, "", infos)
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func listDiskFileInfos(d disk.Disk, root string, prefix string, infos map[string]diskFileInfo) error {
+	entries, err := d.ReadDir(filepath.Join(root, prefix))
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", prefix, err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimPrefix(prefix+"/"+entry.Name(), "/")
+		if entry.IsDir() {
+			err := listDiskFileInfos(d, root, name, infos)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		switch e := entry.(type) {
+		case interface{ Info() (fs.FileInfo, error) }: // local, os.DirEntry
+			info, err := e.Info()
+			if err != nil {
+				return fmt.Errorf("failed to stat %s: %w", name, err)
+			}
+			infos[name] = diskFileInfo{Size: info.Size(), ModTime: info.ModTime()}
+		case fs.FileInfo: // sftp
+			infos[name] = diskFileInfo{Size: e.Size(), ModTime: e.ModTime()}
+		default:
+			return fmt.Errorf("the size of %s is not available", name)
+		}
+	}
+	return nil
+}
+
+func (f *ficsitCLI) listFTPFileInfos(installPath string, root string) (map[string]diskFileInfo, error) {
+	conn, err := f.dialFTP(installPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Quit()
+	}()
+
+	root = path.Clean(filepath.ToSlash(root))
+	infos := make(map[string]diskFileInfo)
+	walker := conn.Walk(root)
+	for walker.Next() {
+		entry := walker.Stat()
+		if entry.Type != ftp.EntryTypeFile {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), root), "/")
+		infos[name] = diskFileInfo{Size: int64(entry.Size), ModTime: entry.Time}
+	}
+	if walker.Err() != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", walker.Path(), walker.Err())
+	}
+	return infos, nil
+}
+
+// dialFTP opens a new connection to an FTP install, logged in with its stored credentials
+func (f *ficsitCLI) dialFTP(installPath string) (*ftp.ServerConn, error) {
+	fullPath, err := f.withCredentials(installPath)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse path: %w", err)
+	}
+	return loginFTP(u)
+}
+
+func loginFTP(u *url.URL) (*ftp.ServerConn, error) {
+	conn, err := ftp.Dial(u.Host, ftp.DialWithTimeout(remoteDialTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial host: %w", err)
+	}
+	password, _ := u.User.Password()
+	err = conn.Login(u.User.Username(), password)
+	if err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+	return conn, nil
+}
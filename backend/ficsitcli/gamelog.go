@@ -0,0 +1,91 @@
+package ficsitcli
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/gamelog"
+)
+
+// AnalyzeGameLog reads the FactoryGame.log of the install, and ranks the mods of its lockfile by how likely they caused the errors in it
+func (f *ficsitCLI) AnalyzeGameLog(installPath string) (*gamelog.Analysis, error) {
+	l := slog.With(slog.String("task", "analyzeGameLog"), slog.String("install", installPath))
+
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.Info == nil {
+		return nil, fmt.Errorf("installation %s is not loaded", installPath)
+	}
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	logPath := filepath.Join(meta.Info.SavedPath, "Logs", "FactoryGame.log")
+	exists, err := d.Exists(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if log exists: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("installation %s has no FactoryGame.log, the game has not been started yet", installPath)
+	}
+	logBytes, err := d.Read(logPath)
+	if err != nil {
+		l.Error("failed to read log", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	mods, err := f.getLogAnalysisMods(l, installation, d)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := gamelog.Analyze(bytes.NewReader(logBytes), mods)
+	if err != nil {
+		l.Error("failed to analyze log", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to analyze log: %w", err)
+	}
+	return analysis, nil
+}
+
+// getLogAnalysisMods returns the mods of the lockfile, with the names of the binaries they installed
+func (f *ficsitCLI) getLogAnalysisMods(l *slog.Logger, installation *cli.Installation, d disk.Disk) ([]gamelog.Mod, error) {
+	lockfile, err := installation.LockFile(f.ficsitCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	if lockfile == nil {
+		return []gamelog.Mod{}, nil
+	}
+
+	modsDirectory := filepath.Join(installation.BasePath(), "FactoryGame", "Mods")
+	mods := make([]gamelog.Mod, 0, len(lockfile.Mods))
+	for modReference, lockedMod := range lockfile.Mods {
+		mod := gamelog.Mod{
+			Reference: modReference,
+			Version:   lockedMod.Version,
+		}
+		// A mod can have several modules, whose names do not have to match the mod reference
+		binaries, err := listDiskFiles(d, filepath.Join(modsDirectory, modReference), "Binaries")
+		if err != nil {
+			l.Warn("failed to list mod binaries", slog.String("mod", modReference), slog.Any("error", err))
+		}
+		for _, binary := range binaries {
+			if module, ok := gamelog.ModuleName(path.Base(binary)); ok {
+				mod.Modules = append(mod.Modules, module)
+			}
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
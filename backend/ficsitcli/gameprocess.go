@@ -0,0 +1,301 @@
+package ficsitcli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/spf13/viper"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type GameSession struct {
+	// Install is empty if the process could not be matched to an install
+	Install    string     `json:"install"`
+	Profile    string     `json:"profile"`
+	PID        int32      `json:"pid"`
+	Executable string     `json:"executable"`
+	StartTime  time.Time  `json:"startTime"`
+	ExitTime   *time.Time `json:"exitTime"`
+	// ExitCode is only known on Windows, other platforms cannot read the exit code of processes SMM did not start
+	ExitCode *int `json:"exitCode"`
+}
+
+type gameSessions struct {
+	running map[int32]*runningGameSession
+	// history is keyed by the hash of the install path, newest last
+	history map[string][]GameSession
+	// deferredApply is set when an apply was blocked by a running game, and must run once it exits
+	deferredApply bool
+	lock          sync.Mutex
+}
+
+type runningGameSession struct {
+	session GameSession
+	// waited sessions are ended by the exit watcher instead of the process poll
+	waited bool
+}
+
+var (
+	gameExitListeners     []func(session GameSession)
+	gameExitListenersLock sync.Mutex
+)
+
+// OnGameExit registers a function that is called in its own goroutine every time a game process exits
+func OnGameExit(listener func(session GameSession)) {
+	gameExitListenersLock.Lock()
+	defer gameExitListenersLock.Unlock()
+	gameExitListeners = append(gameExitListeners, listener)
+}
+
+const (
+	gameProcessPollInterval = 5 * time.Second
+	maxGameSessionHistory   = 20
+	gameSessionsFileName    = "gameSessions.json"
+)
+
+// With and without `.exe` variants in case it is missing on Linux
+var executableNames = []string{
+	"FactoryGame-Win64-Shipping.exe", "FactoryGame-Win64-Shipping",
+	"FactoryGameSteam-Win64-Shipping.exe", "FactoryGameSteam-Win64-Shipping",
+	"FactoryGameEGS-Win64-Shipping.exe", "FactoryGameEGS-Win64-Shipping",
+}
+
+func (f *ficsitCLI) StartGameRunningWatcher() {
+	gameRunningTicker := time.NewTicker(gameProcessPollInterval)
+	go func() {
+		for range gameRunningTicker.C {
+			processes, err := process.Processes()
+			if err != nil {
+				slog.Error("failed to get processes", slog.Any("error", err))
+				continue
+			}
+			f.updateGameSessions(processes)
+			wailsRuntime.EventsEmit(appCommon.AppContext, "isGameRunning", f.isGameRunning)
+		}
+	}()
+}
+
+func (f *ficsitCLI) updateGameSessions(processes []*process.Process) {
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+
+	seen := make(map[int32]bool)
+	for _, p := range processes {
+		name, err := p.Name()
+		if err != nil || !slices.Contains(executableNames, name) {
+			continue
+		}
+		createTime, err := p.CreateTime()
+		if err != nil {
+			continue
+		}
+		startTime := time.UnixMilli(createTime)
+
+		// A different start time means the PID was reused by a new game process
+		if running, ok := f.gameSessions.running[p.Pid]; ok && running.session.StartTime.Equal(startTime) {
+			seen[p.Pid] = true
+			continue
+		}
+		seen[p.Pid] = true
+
+		executable := gameProcessExecutable(p)
+		session := GameSession{
+			Install:    f.findInstallForExecutable(executable),
+			PID:        p.Pid,
+			Executable: executable,
+			StartTime:  startTime,
+		}
+		if installation := f.GetInstallation(session.Install); installation != nil {
+			session.Profile = installation.Profile
+		}
+
+		running := &runningGameSession{session: session}
+		f.gameSessions.running[p.Pid] = running
+		running.waited = watchProcessExit(p.Pid, func(exitCode *int) {
+			f.endGameSession(p.Pid, startTime, exitCode)
+		})
+
+		slog.Info("game started", slog.String("install", session.Install), slog.Int("pid", int(session.PID)))
+		wailsRuntime.EventsEmit(appCommon.AppContext, "gameSessionStarted", session)
+	}
+
+	for pid, running := range f.gameSessions.running {
+		if !seen[pid] && !running.waited {
+			f.endGameSessionLocked(pid, nil)
+		}
+	}
+
+	f.isGameRunning = len(f.gameSessions.running) > 0
+	f.runDeferredApplyLocked()
+}
+
+func (f *ficsitCLI) endGameSession(pid int32, startTime time.Time, exitCode *int) {
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+
+	running, ok := f.gameSessions.running[pid]
+	if !ok || !running.session.StartTime.Equal(startTime) {
+		return
+	}
+	f.endGameSessionLocked(pid, exitCode)
+	f.isGameRunning = len(f.gameSessions.running) > 0
+	f.runDeferredApplyLocked()
+}
+
+func (f *ficsitCLI) endGameSessionLocked(pid int32, exitCode *int) {
+	running := f.gameSessions.running[pid]
+	delete(f.gameSessions.running, pid)
+
+	session := running.session
+	now := time.Now()
+	session.ExitTime = &now
+	session.ExitCode = exitCode
+
+	if session.Install != "" {
+		key := remoteKey(session.Install)
+		history := append(f.gameSessions.history[key], session)
+		if len(history) > maxGameSessionHistory {
+			history = history[len(history)-maxGameSessionHistory:]
+		}
+		f.gameSessions.history[key] = history
+		err := saveGameSessionHistory(f.gameSessions.history)
+		if err != nil {
+			slog.Warn("failed to save game session history", slog.Any("error", err))
+		}
+	}
+
+	logArgs := []any{slog.String("install", session.Install), slog.Int("pid", int(session.PID))}
+	if exitCode != nil {
+		logArgs = append(logArgs, slog.Int("exitCode", *exitCode))
+	}
+	slog.Info("game exited", logArgs...)
+	wailsRuntime.EventsEmit(appCommon.AppContext, "gameSessionEnded", session)
+
+	gameExitListenersLock.Lock()
+	defer gameExitListenersLock.Unlock()
+	for _, listener := range gameExitListeners {
+		go listener(session)
+	}
+}
+
+// GetRunningGameSessions returns the game processes that are currently running
+func (f *ficsitCLI) GetRunningGameSessions() []GameSession {
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+
+	sessions := make([]GameSession, 0, len(f.gameSessions.running))
+	for _, running := range f.gameSessions.running {
+		sessions = append(sessions, running.session)
+	}
+	slices.SortFunc(sessions, func(a, b GameSession) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+	return sessions
+}
+
+// GetGameSessionHistory returns the recent finished game sessions of an install, newest first
+func (f *ficsitCLI) GetGameSessionHistory(installPath string) []GameSession {
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+
+	history := slices.Clone(f.gameSessions.history[remoteKey(installPath)])
+	if history == nil {
+		return []GameSession{}
+	}
+	slices.Reverse(history)
+	return history
+}
+
+func gameProcessExecutable(p *process.Process) string {
+	exe, err := p.Exe()
+	if err == nil && slices.Contains(executableNames, filepath.Base(exe)) {
+		return exe
+	}
+	// Under wine the executable is the wine loader, and the game path is the first argument
+	args, err := p.CmdlineSlice()
+	if err == nil && len(args) > 0 {
+		return wineToNativePath(args[0])
+	}
+	return exe
+}
+
+// wineToNativePath converts a wine Z: drive path, which maps to the root filesystem, to the native path
+func wineToNativePath(path string) string {
+	if runtime.GOOS == "windows" || len(path) < 3 || !strings.EqualFold(path[:3], `Z:\`) {
+		return path
+	}
+	return "/" + strings.ReplaceAll(path[3:], `\`, "/")
+}
+
+// findInstallForExecutable returns the local install that contains the executable
+func (f *ficsitCLI) findInstallForExecutable(executable string) string {
+	if executable == "" {
+		return ""
+	}
+	executable = filepath.Clean(executable)
+	for _, installPath := range f.GetInstallations() {
+		meta, ok := f.installationMetadata.Load(installPath)
+		if !ok || meta.Info == nil || meta.Info.Location != common.LocationTypeLocal {
+			continue
+		}
+		if isPathInside(filepath.Clean(installPath), executable) {
+			return installPath
+		}
+	}
+	return ""
+}
+
+func isPathInside(dir string, path string) bool {
+	if runtime.GOOS == "windows" {
+		dir = strings.ToLower(dir)
+		path = strings.ToLower(path)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+func loadGameSessionHistory() map[string][]GameSession {
+	history := make(map[string][]GameSession)
+	fileBytes, err := os.ReadFile(filepath.Join(viper.GetString("smm-local-dir"), gameSessionsFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read game session history", slog.Any("error", err))
+		}
+		return history
+	}
+	err = json.Unmarshal(fileBytes, &history)
+	if err != nil || history == nil {
+		slog.Warn("failed to parse game session history", slog.Any("error", err))
+		return make(map[string][]GameSession)
+	}
+	return history
+}
+
+func saveGameSessionHistory(history map[string][]GameSession) error {
+	fileBytes, err := utils.JSONMarshal(history, 2)
+	if err != nil {
+		return fmt.Errorf("failed to marshal game session history: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(viper.GetString("smm-local-dir"), gameSessionsFileName), fileBytes, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write game session history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package ficsitcli
+
+// watchProcessExit is not possible for processes that are not children of SMM,
+// so the exit is detected by the process poll instead, without an exit code
+func watchProcessExit(_ int32, _ func(exitCode *int)) bool {
+	return false
+}
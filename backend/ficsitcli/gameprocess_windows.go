@@ -0,0 +1,35 @@
+package ficsitcli
+
+import (
+	"log/slog"
+
+	"golang.org/x/sys/windows"
+)
+
+// watchProcessExit keeps a handle to the process, so its exit code can be read after it exits
+func watchProcessExit(pid int32, onExit func(exitCode *int)) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION|windows.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		slog.Warn("failed to open game process, exit code will not be available", slog.Int("pid", int(pid)), slog.Any("error", err))
+		return false
+	}
+	go func() {
+		defer windows.CloseHandle(handle) //nolint:errcheck
+		_, err := windows.WaitForSingleObject(handle, windows.INFINITE)
+		if err != nil {
+			slog.Warn("failed to wait for game process", slog.Int("pid", int(pid)), slog.Any("error", err))
+			onExit(nil)
+			return
+		}
+		var code uint32
+		err = windows.GetExitCodeProcess(handle, &code)
+		if err != nil {
+			slog.Warn("failed to get game exit code", slog.Int("pid", int(pid)), slog.Any("error", err))
+			onExit(nil)
+			return
+		}
+		exitCode := int(int32(code))
+		onExit(&exitCode)
+	}()
+	return true
+}
@@ -0,0 +1,104 @@
+package ficsitcli
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/shirou/gopsutil/v3/process"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+// GameRunningError is returned when an install cannot be modified because its game is running
+type GameRunningError struct {
+	Install string `json:"install"`
+	PID     int32  `json:"pid"`
+	// Deferred is true when the apply will run automatically once the game exits
+	Deferred bool `json:"deferred"`
+}
+
+func (e GameRunningError) Error() string {
+	if e.Deferred {
+		return fmt.Sprintf("the game is running from %s, changes will be applied when it exits", e.Install)
+	}
+	return fmt.Sprintf("the game is running from %s, close it before applying changes", e.Install)
+}
+
+// checkGameNotRunning returns a GameRunningError if the game of any of the installs is running.
+// The processes are checked again, since the watcher might not have seen a game that just started
+func (f *ficsitCLI) checkGameNotRunning(installPaths ...string) error {
+	processes, err := process.Processes()
+	if err != nil {
+		slog.Warn("failed to get processes, using the last known running games", slog.Any("error", err))
+	} else {
+		f.updateGameSessions(processes)
+	}
+
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+
+	for _, running := range f.gameSessions.running {
+		for _, installPath := range installPaths {
+			if running.session.Install == installPath {
+				return GameRunningError{Install: installPath, PID: running.session.PID}
+			}
+		}
+	}
+	return nil
+}
+
+// checkApplyAllowed refuses to modify installs whose game is running,
+// and if enabled in settings, queues the apply to run when the game exits
+func (f *ficsitCLI) checkApplyAllowed(installPaths ...string) error {
+	err := f.checkGameNotRunning(installPaths...)
+	var gameRunningErr GameRunningError
+	if !errors.As(err, &gameRunningErr) || !settings.Settings.DeferApplyWhileGameRunning {
+		return err
+	}
+
+	f.gameSessions.lock.Lock()
+	f.gameSessions.deferredApply = true
+	f.gameSessions.lock.Unlock()
+	wailsRuntime.EventsEmit(appCommon.AppContext, "deferredApply", true)
+
+	gameRunningErr.Deferred = true
+	return gameRunningErr
+}
+
+// runDeferredApplyLocked applies the queued changes once no game is running anymore.
+// Must be called with the game sessions lock held
+func (f *ficsitCLI) runDeferredApplyLocked() {
+	if !f.gameSessions.deferredApply || len(f.gameSessions.running) > 0 {
+		return
+	}
+	f.gameSessions.deferredApply = false
+	wailsRuntime.EventsEmit(appCommon.AppContext, "deferredApply", false)
+
+	go func() {
+		slog.Info("game exited, applying deferred changes")
+		err := f.Apply()
+		if err != nil {
+			slog.Error("failed to apply deferred changes", slog.Any("error", err))
+		}
+	}()
+}
+
+func (f *ficsitCLI) GetDeferredApply() bool {
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+	return f.gameSessions.deferredApply
+}
+
+// CancelDeferredApply drops the queued apply, the changes stay in the profile and are applied next time
+func (f *ficsitCLI) CancelDeferredApply() {
+	f.gameSessions.lock.Lock()
+	defer f.gameSessions.lock.Unlock()
+	if !f.gameSessions.deferredApply {
+		return
+	}
+	f.gameSessions.deferredApply = false
+	wailsRuntime.EventsEmit(appCommon.AppContext, "deferredApply", false)
+}
@@ -4,11 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/satisfactorymodding/ficsit-cli/cli"
 	resolver "github.com/satisfactorymodding/ficsit-resolver"
 
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
 )
 
 func (f *ficsitCLI) initInstallations() error {
@@ -183,6 +187,18 @@ func (f *ficsitCLI) GetSelectedInstallLockfileMods() (map[string]resolver.Locked
 	return lockfile.Mods, nil
 }
 
+func (f *ficsitCLI) GetInstallLockfile(installPath string) (*resolver.LockFile, error) {
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", installPath)
+	}
+	lockfile, err := installation.LockFile(f.ficsitCli)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return lockfile, nil
+}
+
 func (f *ficsitCLI) GetSelectedInstallLockfile() (*resolver.LockFile, error) {
 	selectedInstallation := f.GetSelectedInstall()
 	if selectedInstallation == nil {
@@ -195,7 +211,31 @@ func (f *ficsitCLI) GetSelectedInstallLockfile() (*resolver.LockFile, error) {
 	return lockfile, nil
 }
 
+var (
+	lockfileCleaner = regexp.MustCompile(`[^a-zA-Z\d]]`)
+	matchFirstCap   = regexp.MustCompile(`(.)([A-Z][a-z]+)`)
+	matchAllCap     = regexp.MustCompile(`([a-z\d])([A-Z])`)
+)
+
+// profileLockfilePath returns the path of the lockfile of a profile in an installation.
+// ficsit-cli does not export this, so the naming must be kept in sync with it
+func profileLockfilePath(installation *cli.Installation, profileName string) string {
+	lockfileName := profileName
+	lockfileName = matchFirstCap.ReplaceAllString(lockfileName, "${1}_${2}")
+	lockfileName = matchAllCap.ReplaceAllString(lockfileName, "${1}_${2}")
+	lockfileName = lockfileCleaner.ReplaceAllLiteralString(lockfileName, "-")
+	lockfileName = strings.ToLower(lockfileName) + "-lock.json"
+
+	return filepath.Join(installation.BasePath(), "FactoryGame", "Mods", lockfileName)
+}
+
+// LaunchGame launches the selected install. If the pre-launch check is enabled and finds issues,
+// the game is not launched, and the issues are sent in a launchCheckFailed event
 func (f *ficsitCLI) LaunchGame() {
+	f.launchGame(settings.Settings.PreLaunchCheck)
+}
+
+func (f *ficsitCLI) launchGame(check bool) {
 	selectedInstallation := f.GetSelectedInstall()
 	if selectedInstallation == nil {
 		slog.Error("no installation selected")
@@ -206,7 +246,16 @@ func (f *ficsitCLI) LaunchGame() {
 		slog.Error("no metadata for installation")
 		return
 	}
-	out, cmd, err := f.executeLaunchCommand(metadata.Info.LaunchPath)
+	if check && !f.runPreLaunchCheck(selectedInstallation.Path) {
+		return
+	}
+	launchOptions, err := f.resolveLaunchOptions(selectedInstallation, metadata.Info)
+	if err != nil {
+		slog.Error("failed to build launch command", slog.Any("error", err))
+		return
+	}
+	f.recordLaunch(selectedInstallation)
+	out, cmd, err := f.executeLaunchCommand(launchOptions.Command, launchOptions.Env)
 	if err != nil {
 		slog.Error("failed to launch game", slog.Any("error", err), slog.String("cmd", cmd), slog.String("output", string(out)))
 		return
@@ -5,8 +5,9 @@ import (
 	"syscall"
 )
 
-func (f *ficsitCLI) executeLaunchCommand(launchPath []string) ([]byte, string, error) {
+func (f *ficsitCLI) executeLaunchCommand(launchPath []string, env map[string]string) ([]byte, string, error) {
 	cmd := exec.Command(launchPath[0], launchPath[1:]...)
+	cmd.Env = mergeEnv(env)
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 	out, err := cmd.CombinedOutput()
 	return out, cmd.String(), err
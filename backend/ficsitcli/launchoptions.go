@@ -0,0 +1,221 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/exp/maps"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type ResolvedLaunchOptions struct {
+	Command []string          `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+}
+
+var (
+	envNameRegex        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	launchTemplateNames = []string{"install", "profile", "saved"}
+)
+
+func (f *ficsitCLI) GetInstallLaunchOptions(installPath string) settings.LaunchOptions {
+	return settings.Settings.InstallLaunchOptions[remoteKey(installPath)]
+}
+
+func (f *ficsitCLI) SetInstallLaunchOptions(installPath string, options settings.LaunchOptions) error {
+	if f.GetInstallation(installPath) == nil {
+		return fmt.Errorf("installation %s not found", installPath)
+	}
+	err := validateLaunchOptions(options)
+	if err != nil {
+		return err
+	}
+	if meta, ok := f.installationMetadata.Load(installPath); ok && meta.Info != nil {
+		err = meta.Info.CheckLaunchEnv(options.Env)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	if settings.Settings.InstallLaunchOptions == nil {
+		settings.Settings.InstallLaunchOptions = map[string]settings.LaunchOptions{}
+	}
+	if len(options.Args) == 0 && len(options.Env) == 0 {
+		delete(settings.Settings.InstallLaunchOptions, remoteKey(installPath))
+	} else {
+		settings.Settings.InstallLaunchOptions[remoteKey(installPath)] = options
+	}
+	_ = settings.SaveSettings()
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "launchOptions", installPath)
+	return nil
+}
+
+func (f *ficsitCLI) GetProfileLaunchOptions(profileName string) settings.LaunchOptions {
+	return settings.Settings.ProfileLaunchOptions[profileName]
+}
+
+// SetProfileLaunchOptions sets launch options used by any install while it uses the profile.
+// The arguments are added after the install's, and the environment variables override the install's.
+// Installs whose launcher does not support them fail to launch with ErrLaunchArgsUnsupported or ErrLaunchEnvUnsupported
+func (f *ficsitCLI) SetProfileLaunchOptions(profileName string, options settings.LaunchOptions) error {
+	if f.GetProfile(profileName) == nil {
+		return fmt.Errorf("profile %s not found", profileName)
+	}
+	err := validateLaunchOptions(options)
+	if err != nil {
+		return err
+	}
+
+	if settings.Settings.ProfileLaunchOptions == nil {
+		settings.Settings.ProfileLaunchOptions = map[string]settings.LaunchOptions{}
+	}
+	if len(options.Args) == 0 && len(options.Env) == 0 {
+		delete(settings.Settings.ProfileLaunchOptions, profileName)
+	} else {
+		settings.Settings.ProfileLaunchOptions[profileName] = options
+	}
+	_ = settings.SaveSettings()
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "launchOptions", nil)
+	return nil
+}
+
+func (f *ficsitCLI) renameProfileLaunchOptions(oldName string, newName string) {
+	options, ok := settings.Settings.ProfileLaunchOptions[oldName]
+	if !ok {
+		return
+	}
+	delete(settings.Settings.ProfileLaunchOptions, oldName)
+	settings.Settings.ProfileLaunchOptions[newName] = options
+	_ = settings.SaveSettings()
+}
+
+func (f *ficsitCLI) deleteProfileLaunchOptions(name string) {
+	if _, ok := settings.Settings.ProfileLaunchOptions[name]; !ok {
+		return
+	}
+	delete(settings.Settings.ProfileLaunchOptions, name)
+	_ = settings.SaveSettings()
+}
+
+// GetLaunchCommand returns the command LaunchGame would run for the install, with its current profile
+func (f *ficsitCLI) GetLaunchCommand(installPath string) (*ResolvedLaunchOptions, error) {
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.Info == nil {
+		return nil, fmt.Errorf("installation %s is not loaded", installPath)
+	}
+	return f.resolveLaunchOptions(installation, meta.Info)
+}
+
+func (f *ficsitCLI) resolveLaunchOptions(installation *cli.Installation, info *common.Installation) (*ResolvedLaunchOptions, error) {
+	installOptions := f.GetInstallLaunchOptions(installation.Path)
+	profileOptions := f.GetProfileLaunchOptions(installation.Profile)
+
+	values := map[string]string{
+		"install": installation.Path,
+		"profile": installation.Profile,
+		"saved":   info.SavedPath,
+	}
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string { return values[name] })
+	}
+
+	resolved := &ResolvedLaunchOptions{
+		Args: make([]string, 0, len(installOptions.Args)+len(profileOptions.Args)),
+		Env:  make(map[string]string, len(installOptions.Env)+len(profileOptions.Env)),
+	}
+	for _, arg := range installOptions.Args {
+		resolved.Args = append(resolved.Args, expand(arg))
+	}
+	for _, arg := range profileOptions.Args {
+		resolved.Args = append(resolved.Args, expand(arg))
+	}
+	for name, value := range installOptions.Env {
+		resolved.Env[name] = expand(value)
+	}
+	for name, value := range profileOptions.Env {
+		resolved.Env[name] = expand(value)
+	}
+
+	// Profile options are not checked against every install when set, so the environment is also checked here
+	err := info.CheckLaunchEnv(resolved.Env)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	command, err := info.BuildLaunchCommand(resolved.Args)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("installation %s cannot be launched", installation.Path)
+	}
+	resolved.Command = command
+
+	return resolved, nil
+}
+
+func validateLaunchOptions(options settings.LaunchOptions) error {
+	for _, arg := range options.Args {
+		if strings.TrimSpace(arg) == "" {
+			return fmt.Errorf("launch arguments must not be empty")
+		}
+		if strings.ContainsAny(arg, "\x00\r\n") {
+			return fmt.Errorf("launch argument %q contains invalid characters", arg)
+		}
+		err := validateLaunchTemplate(arg)
+		if err != nil {
+			return err
+		}
+	}
+	for name, value := range options.Env {
+		if !envNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("environment variable %s contains invalid characters", name)
+		}
+		err := validateLaunchTemplate(value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateLaunchTemplate(s string) error {
+	var unknown []string
+	os.Expand(s, func(name string) string {
+		if !slices.Contains(launchTemplateNames, name) {
+			unknown = append(unknown, name)
+		}
+		return ""
+	})
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown variable ${%s} in %q, supported variables are ${%s}", unknown[0], s, strings.Join(launchTemplateNames, "}, ${"))
+	}
+	return nil
+}
+
+func mergeEnv(env map[string]string) []string {
+	names := maps.Keys(env)
+	slices.Sort(names)
+	merged := os.Environ()
+	for _, name := range names {
+		merged = append(merged, name+"="+env[name])
+	}
+	return merged
+}
@@ -0,0 +1,540 @@
+package ficsitcli
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
+	"github.com/satisfactorymodding/ficsit-cli/cli/provider"
+	"github.com/satisfactorymodding/ficsit-cli/ficsit"
+	ficsitUtils "github.com/satisfactorymodding/ficsit-cli/utils"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type LocalModTarget struct {
+	CacheKey string `json:"cacheKey"`
+	Hash     string `json:"hash"`
+	Size     int64  `json:"size"`
+}
+
+type LocalMod struct {
+	ModReference string                    `json:"modReference"`
+	Name         string                    `json:"name"`
+	Author       string                    `json:"author"`
+	Version      string                    `json:"version"`
+	GameVersion  string                    `json:"gameVersion"`
+	Dependencies []resolver.Dependency     `json:"dependencies"`
+	Targets      map[string]LocalModTarget `json:"targets"`
+	Source       string                    `json:"source"`
+	AddedAt      time.Time                 `json:"addedAt"`
+}
+
+type localModsFile struct {
+	Mods map[string]LocalMod `json:"mods"`
+}
+
+var localModsFileName = "localMods.json"
+
+// localModsProvider adds the locally installed mod archives to the versions provided by ficsit-cli,
+// so that the resolver can pick them like any other version.
+// Like the ficsit-cli mixed provider, it uses either the online or the offline provider
+type localModsProvider struct {
+	onlineProvider  provider.Provider
+	offlineProvider provider.Provider
+	// offline is switched by the connectivity watcher while mods are being resolved and installed
+	offline  atomic.Bool
+	mods     map[string]LocalMod
+	modsLock sync.RWMutex
+}
+
+var _ provider.Provider = (*localModsProvider)(nil)
+
+func newLocalModsProvider(onlineProvider provider.Provider, offlineProvider provider.Provider, offline bool) (*localModsProvider, error) {
+	p := &localModsProvider{
+		onlineProvider:  onlineProvider,
+		offlineProvider: offlineProvider,
+		mods:            make(map[string]LocalMod),
+	}
+	p.offline.Store(offline)
+	err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *localModsProvider) current() provider.Provider {
+	if p.offline.Load() {
+		return p.offlineProvider
+	}
+	return p.onlineProvider
+}
+
+func (p *localModsProvider) SetOffline(offline bool) {
+	p.offline.Store(offline)
+}
+
+func (p *localModsProvider) IsOffline() bool {
+	return p.offline.Load()
+}
+
+func (p *localModsProvider) Mods(ctx context.Context, filter ficsit.ModFilter) (*ficsit.ModsResponse, error) {
+	return p.current().Mods(ctx, filter) //nolint:wrapcheck
+}
+
+func (p *localModsProvider) GetMod(ctx context.Context, modReference string) (*ficsit.GetModResponse, error) {
+	return p.current().GetMod(ctx, modReference) //nolint:wrapcheck
+}
+
+func (p *localModsProvider) getLocalMod(modReference string) (LocalMod, bool) {
+	p.modsLock.RLock()
+	defer p.modsLock.RUnlock()
+	mod, ok := p.mods[modReference]
+	return mod, ok
+}
+
+func (p *localModsProvider) ModVersionsWithDependencies(ctx context.Context, modID string) ([]resolver.ModVersion, error) {
+	localMod, ok := p.getLocalMod(modID)
+	versions, err := p.current().ModVersionsWithDependencies(ctx, modID)
+	if !ok {
+		return versions, err //nolint:wrapcheck
+	}
+	if err != nil {
+		// Mods that only exist locally are not found in the repository
+		slog.Debug("failed to get repository versions of local mod", slog.String("mod", modID), slog.Any("error", err))
+	}
+
+	versions = slices.DeleteFunc(slices.Clone(versions), func(version resolver.ModVersion) bool {
+		return version.Version == localMod.Version
+	})
+
+	targets := make([]resolver.Target, 0, len(localMod.Targets))
+	for targetName, target := range localMod.Targets {
+		targets = append(targets, resolver.Target{
+			TargetName: resolver.TargetName(targetName),
+			Link:       localModLink(localMod, targetName),
+			Hash:       target.Hash,
+			Size:       target.Size,
+		})
+	}
+
+	return append(versions, resolver.ModVersion{
+		Version:      localMod.Version,
+		GameVersion:  localMod.GameVersion,
+		Dependencies: localMod.Dependencies,
+		Targets:      targets,
+	}), nil
+}
+
+func (p *localModsProvider) GetModName(ctx context.Context, modReference string) (*resolver.ModName, error) {
+	name, err := p.current().GetModName(ctx, modReference)
+	if err == nil {
+		return name, nil
+	}
+	localMod, ok := p.getLocalMod(modReference)
+	if !ok {
+		return nil, err //nolint:wrapcheck
+	}
+	return &resolver.ModName{
+		ID:           modReference,
+		ModReference: modReference,
+		Name:         localMod.Name,
+	}, nil
+}
+
+func (p *localModsProvider) load() error {
+	p.modsLock.Lock()
+	defer p.modsLock.Unlock()
+
+	fileBytes, err := os.ReadFile(filepath.Join(viper.GetString("smm-local-dir"), localModsFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read local mods: %w", err)
+	}
+
+	var file localModsFile
+	err = json.Unmarshal(fileBytes, &file)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal local mods: %w", err)
+	}
+	if file.Mods != nil {
+		p.mods = file.Mods
+	}
+	return nil
+}
+
+func (p *localModsProvider) save() error {
+	p.modsLock.RLock()
+	defer p.modsLock.RUnlock()
+
+	fileBytes, err := utils.JSONMarshal(localModsFile{Mods: p.mods}, 2)
+	if err != nil {
+		return fmt.Errorf("failed to marshal local mods: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(viper.GetString("smm-local-dir"), localModsFileName), fileBytes, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write local mods: %w", err)
+	}
+	return nil
+}
+
+func localModLink(mod LocalMod, target string) string {
+	// The archive is always served from the download cache, the link is only informative
+	return "local://" + mod.ModReference + "/" + mod.Version + "/" + target
+}
+
+func (f *ficsitCLI) localMods() *localModsProvider {
+	return f.ficsitCli.Provider.(*localModsProvider)
+}
+
+func (f *ficsitCLI) GetLocalMods() []LocalMod {
+	p := f.localMods()
+	p.modsLock.RLock()
+	defer p.modsLock.RUnlock()
+
+	mods := make([]LocalMod, 0, len(p.mods))
+	for _, mod := range p.mods {
+		mods = append(mods, mod)
+	}
+	slices.SortFunc(mods, func(a, b LocalMod) int {
+		return strings.Compare(a.ModReference, b.ModReference)
+	})
+	return mods
+}
+
+// InstallLocalMod registers a mod archive that is not published on the repository,
+// and adds its version to the selected profile
+func (f *ficsitCLI) InstallLocalMod(path string) error {
+	return f.action(ActionInstall, newSimpleItem(filepath.Base(path)), func(l *slog.Logger, taskUpdates chan<- taskUpdate) error {
+		selectedInstallation := f.GetSelectedInstall()
+		if selectedInstallation == nil {
+			return fmt.Errorf("no installation selected")
+		}
+
+		l = l.With(
+			slog.String("install", selectedInstallation.Path),
+			slog.String("profile", selectedInstallation.Profile),
+			slog.String("file", path),
+		)
+
+		localMod, err := addLocalModArchive(path)
+		if err != nil {
+			l.Error("failed to read local mod", slog.Any("error", err))
+			return fmt.Errorf("failed to read local mod: %w", err)
+		}
+
+		l = l.With(slog.String("mod", localMod.ModReference), slog.String("version", localMod.Version))
+
+		p := f.localMods()
+		p.modsLock.Lock()
+		p.mods[localMod.ModReference] = *localMod
+		p.modsLock.Unlock()
+
+		err = p.save()
+		if err != nil {
+			l.Error("failed to save local mods", slog.Any("error", err))
+		}
+
+		_, err = ficsitcache.LoadCacheMods()
+		if err != nil {
+			l.Warn("failed to reload cache", slog.Any("error", err))
+		}
+
+		wailsRuntime.EventsEmit(appCommon.AppContext, "localMods", f.GetLocalMods())
+
+		profile := f.GetProfile(selectedInstallation.Profile)
+
+		profileErr := profile.AddMod(localMod.ModReference, localMod.Version)
+		if profileErr != nil {
+			l.Error("failed to add mod", slog.Any("error", profileErr))
+			return fmt.Errorf("failed to add mod: %s@%s: %w", localMod.ModReference, localMod.Version, profileErr)
+		}
+
+		err = f.ficsitCli.Profiles.Save()
+		if err != nil {
+			l.Error("failed to save profile", slog.Any("error", err))
+		}
+
+		// The lockfile might point at a repository build of the same version, which must be replaced
+		err = selectedInstallation.UpdateMods(f.ficsitCli, []string{localMod.ModReference})
+		if err != nil {
+			l.Error("failed to update lockfile", slog.Any("error", err))
+			var solvingError resolver.DependencyResolverError
+			if errors.As(err, &solvingError) {
+				return solvingError
+			}
+			return err //nolint:wrapcheck
+		}
+
+		installErr := f.apply(l, taskUpdates)
+		if installErr != nil {
+			l.Error("failed to install", slog.Any("error", installErr))
+			return installErr
+		}
+
+		return nil
+	})
+}
+
+// RemoveLocalMod unregisters a local mod. Profiles using it will use the repository versions instead
+func (f *ficsitCLI) RemoveLocalMod(modReference string) error {
+	p := f.localMods()
+	p.modsLock.Lock()
+	_, ok := p.mods[modReference]
+	delete(p.mods, modReference)
+	p.modsLock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("local mod %s not found", modReference)
+	}
+
+	err := p.save()
+	if err != nil {
+		return err
+	}
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "localMods", f.GetLocalMods())
+	return nil
+}
+
+var localModArchiveExtensions = []string{".zip", ".smod"}
+
+func IsLocalModArchive(path string) bool {
+	return slices.Contains(localModArchiveExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// addLocalModArchive reads the mod's metadata from the archive,
+// and stores one archive per target in the download cache, where installs will find it
+func addLocalModArchive(archivePath string) (*LocalMod, error) {
+	if !IsLocalModArchive(archivePath) {
+		return nil, fmt.Errorf("unsupported file type %s", filepath.Ext(archivePath))
+	}
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	// Multi-target archives contain one directory per target, each with its own .uplugin
+	upluginFiles := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		if !strings.HasSuffix(file.Name, ".uplugin") {
+			continue
+		}
+		dir := path.Dir(file.Name)
+		if dir == "." || slices.Contains(allTargetNames, resolver.TargetName(dir)) {
+			upluginFiles[dir] = file
+		}
+	}
+	if len(upluginFiles) == 0 {
+		return nil, fmt.Errorf("no .uplugin file found in archive")
+	}
+	if _, ok := upluginFiles["."]; ok && len(upluginFiles) > 1 {
+		return nil, fmt.Errorf("archive contains both a single target and a multi-target mod")
+	}
+
+	var modReference string
+	var uplugin *ficsitcache.UPlugin
+	for _, file := range upluginFiles {
+		reference := strings.TrimSuffix(path.Base(file.Name), ".uplugin")
+		if modReference != "" && reference != modReference {
+			return nil, fmt.Errorf("archive contains multiple mods: %s, %s", modReference, reference)
+		}
+		modReference = reference
+		if uplugin == nil {
+			uplugin, err = readUPlugin(file)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if uplugin.SemVersion == "" {
+		return nil, fmt.Errorf("%s.uplugin does not specify a SemVersion", modReference)
+	}
+	if !ficsitUtils.SemVerRegex.MatchString(uplugin.SemVersion) {
+		return nil, fmt.Errorf("invalid SemVersion %s", uplugin.SemVersion)
+	}
+
+	localMod := &LocalMod{
+		ModReference: modReference,
+		Name:         uplugin.FriendlyName,
+		Author:       uplugin.CreatedBy,
+		Version:      uplugin.SemVersion,
+		GameVersion:  uplugin.GameVersion,
+		Dependencies: make([]resolver.Dependency, 0, len(uplugin.Plugins)),
+		Targets:      make(map[string]LocalModTarget),
+		Source:       archivePath,
+		AddedAt:      time.Now(),
+	}
+	if localMod.Name == "" {
+		localMod.Name = modReference
+	}
+	for _, plugin := range uplugin.Plugins {
+		if plugin.BasePlugin {
+			// Engine and game plugins are not mods
+			continue
+		}
+		condition := plugin.SemVersion
+		if condition == "" {
+			condition = ">=0.0.0"
+		}
+		localMod.Dependencies = append(localMod.Dependencies, resolver.Dependency{
+			ModID:     plugin.Name,
+			Condition: condition,
+			Optional:  plugin.Optional,
+		})
+	}
+
+	downloadCache := downloadCacheDir()
+	err = os.MkdirAll(downloadCache, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download cache: %w", err)
+	}
+
+	for dir := range upluginFiles {
+		var targetName string
+		var data []byte
+		if dir == "." {
+			targetName = detectLocalModTarget(archivePath, archive.File)
+			data, err = os.ReadFile(archivePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read archive: %w", err)
+			}
+		} else {
+			targetName = dir
+			data, err = extractTargetArchive(archive.File, dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract %s from archive: %w", targetName, err)
+			}
+		}
+
+		hash, err := ficsitUtils.SHA256Data(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash archive: %w", err)
+		}
+
+		cacheKey := modReference + "_" + localMod.Version + "_" + targetName + ".zip"
+		err = os.WriteFile(filepath.Join(downloadCache, cacheKey), data, 0o755)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write archive to cache: %w", err)
+		}
+
+		localMod.Targets[targetName] = LocalModTarget{
+			CacheKey: cacheKey,
+			Hash:     hash,
+			Size:     int64(len(data)),
+		}
+	}
+
+	return localMod, nil
+}
+
+func readUPlugin(file *zip.File) (*ficsitcache.UPlugin, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+
+	return parseUPlugin(file.Name, data)
+}
+
+func parseUPlugin(name string, data []byte) (*ficsitcache.UPlugin, error) {
+	// Some editors save the .uplugin with a BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var uplugin ficsitcache.UPlugin
+	err := json.Unmarshal(data, &uplugin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return &uplugin, nil
+}
+
+// detectLocalModTarget guesses the target of a single target archive,
+// first from the file name suffix used by Alpakit, then from the binaries it contains
+func detectLocalModTarget(archivePath string, files []*zip.File) string {
+	name := strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))
+	// WindowsServer must be checked before Windows
+	for _, target := range []resolver.TargetName{resolver.TargetNameWindowsServer, resolver.TargetNameLinuxServer, resolver.TargetNameWindows} {
+		if strings.HasSuffix(name, "-"+string(target)) || strings.HasSuffix(name, "_"+string(target)) {
+			return string(target)
+		}
+	}
+	for _, file := range files {
+		if strings.HasPrefix(file.Name, "Binaries/Linux/") {
+			return string(resolver.TargetNameLinuxServer)
+		}
+	}
+	return string(resolver.TargetNameWindows)
+}
+
+func extractTargetArchive(files []*zip.File, target string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	prefix := target + "/"
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name, prefix) || file.FileInfo().IsDir() {
+			continue
+		}
+
+		header := file.FileHeader
+		header.Name = strings.TrimPrefix(file.Name, prefix)
+		header.Method = zip.Deflate
+
+		err := func() error {
+			fileWriter, err := writer.CreateHeader(&header)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", header.Name, err)
+			}
+			reader, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", file.Name, err)
+			}
+			defer reader.Close()
+			_, err = io.Copy(fileWriter, reader)
+			if err != nil {
+				return fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			return nil
+		}()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,427 @@
+package ficsitcli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/gamelog"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+)
+
+type LogTailUpdate struct {
+	Install string `json:"install"`
+	// Reset is set when the log was replaced, such as when the game restarted, and the previous lines must be discarded
+	Reset bool           `json:"reset"`
+	Lines []gamelog.Line `json:"lines"`
+}
+
+type logTails struct {
+	tails map[string]*logTail
+	lock  sync.Mutex
+}
+
+type logTail struct {
+	install string
+	logPath string
+	d       disk.Disk
+	local   bool
+	// dialFTP is set for FTP installs, whose log is read from the last offset over a separate connection
+	dialFTP func() (*ftp.ServerConn, error)
+	ftpConn *ftp.ServerConn
+
+	offset int64
+	// localFile is used to detect the log being replaced by a new one of a larger size between polls
+	localFile os.FileInfo
+	// pending is the end of the log that is not a full line yet
+	pending    []byte
+	lineNumber int
+	// lines keeps all levels, so that lowering the level shows the lines logged before
+	lines    []gamelog.Line
+	minLevel gamelog.Level
+
+	stop chan struct{}
+	lock sync.Mutex
+}
+
+const (
+	localLogTailInterval = 1 * time.Second
+	// Remote logs are read over the network, so they are polled less often
+	remoteLogTailInterval = 5 * time.Second
+	maxLogTailLines       = 10000
+)
+
+// StartLogTail starts emitting the new lines of the FactoryGame.log of the install as logTail events.
+// If the install is already being tailed, only the level is changed
+func (f *ficsitCLI) StartLogTail(installPath string, minLevel gamelog.Level) error {
+	if !gamelog.ValidLevel(minLevel) {
+		return fmt.Errorf("invalid log level %s", minLevel)
+	}
+
+	f.logTails.lock.Lock()
+	defer f.logTails.lock.Unlock()
+
+	if tail, ok := f.logTails.tails[installPath]; ok {
+		tail.setLevel(minLevel)
+		return nil
+	}
+
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.Info == nil {
+		return fmt.Errorf("installation %s is not loaded", installPath)
+	}
+	d, err := installation.GetDisk()
+	if err != nil {
+		return fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	tail := &logTail{
+		install:  installPath,
+		logPath:  filepath.Join(meta.Info.SavedPath, "Logs", "FactoryGame.log"),
+		d:        d,
+		local:    meta.Info.Location == common.LocationTypeLocal,
+		lines:    []gamelog.Line{},
+		minLevel: minLevel,
+		stop:     make(chan struct{}),
+	}
+	if parsed, err := url.Parse(installPath); err == nil && parsed.Scheme == "ftp" {
+		tail.dialFTP = func() (*ftp.ServerConn, error) {
+			return f.dialFTP(installPath)
+		}
+	}
+	f.logTails.tails[installPath] = tail
+
+	go tail.run()
+	return nil
+}
+
+func (f *ficsitCLI) StopLogTail(installPath string) {
+	f.logTails.lock.Lock()
+	defer f.logTails.lock.Unlock()
+
+	tail, ok := f.logTails.tails[installPath]
+	if !ok {
+		return
+	}
+	close(tail.stop)
+	delete(f.logTails.tails, installPath)
+}
+
+// SetLogTailLevel changes the minimum level of the lines emitted for the install, and returns the kept lines at that level
+func (f *ficsitCLI) SetLogTailLevel(installPath string, minLevel gamelog.Level) ([]gamelog.Line, error) {
+	if !gamelog.ValidLevel(minLevel) {
+		return nil, fmt.Errorf("invalid log level %s", minLevel)
+	}
+	tail, err := f.getLogTail(installPath)
+	if err != nil {
+		return nil, err
+	}
+	tail.setLevel(minLevel)
+	return tail.search(""), nil
+}
+
+// GetLogTailLines returns the last lines of the log that are at least at the level of the tail
+func (f *ficsitCLI) GetLogTailLines(installPath string) ([]gamelog.Line, error) {
+	tail, err := f.getLogTail(installPath)
+	if err != nil {
+		return nil, err
+	}
+	return tail.search(""), nil
+}
+
+// SearchLogTail returns the kept lines at the level of the tail that contain the query, ignoring case
+func (f *ficsitCLI) SearchLogTail(installPath string, query string) ([]gamelog.Line, error) {
+	tail, err := f.getLogTail(installPath)
+	if err != nil {
+		return nil, err
+	}
+	return tail.search(query), nil
+}
+
+func (f *ficsitCLI) getLogTail(installPath string) (*logTail, error) {
+	f.logTails.lock.Lock()
+	defer f.logTails.lock.Unlock()
+	tail, ok := f.logTails.tails[installPath]
+	if !ok {
+		return nil, fmt.Errorf("log of %s is not being tailed", installPath)
+	}
+	return tail, nil
+}
+
+func (t *logTail) run() {
+	l := slog.With(slog.String("task", "logTail"), slog.String("install", t.install))
+
+	interval := remoteLogTailInterval
+	if t.local {
+		interval = localLogTailInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer t.closeFTP()
+
+	lastErr := ""
+	for {
+		err := t.poll()
+		if err != nil {
+			// Only log when the error changes, since it would repeat on every poll while the server is unreachable
+			if err.Error() != lastErr {
+				l.Warn("failed to read log", slog.Any("error", err))
+			}
+			lastErr = err.Error()
+		} else {
+			lastErr = ""
+		}
+
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *logTail) poll() error {
+	data, reset, err := t.readNew()
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	if reset {
+		t.offset = 0
+		t.pending = nil
+		t.lineNumber = 0
+		t.lines = []gamelog.Line{}
+	}
+	t.offset += int64(len(data))
+
+	chunk := append(t.pending, data...)
+	lastNewline := bytes.LastIndexByte(chunk, '\n')
+	if lastNewline == -1 {
+		t.pending = chunk
+		chunk = nil
+	} else {
+		t.pending = bytes.Clone(chunk[lastNewline+1:])
+		chunk = chunk[:lastNewline]
+	}
+
+	var newLines []gamelog.Line
+	if chunk != nil {
+		for _, text := range strings.Split(string(chunk), "\n") {
+			t.lineNumber++
+			line := gamelog.ParseLine(t.lineNumber, strings.TrimRight(text, "\r"))
+			t.lines = append(t.lines, line)
+			if line.Level.AtLeast(t.minLevel) {
+				newLines = append(newLines, line)
+			}
+		}
+		if len(t.lines) > maxLogTailLines {
+			t.lines = append([]gamelog.Line{}, t.lines[len(t.lines)-maxLogTailLines:]...)
+		}
+		// The first read of a long log would otherwise send every line
+		if len(newLines) > maxLogTailLines {
+			newLines = newLines[len(newLines)-maxLogTailLines:]
+		}
+	}
+	t.lock.Unlock()
+
+	if !reset && len(newLines) == 0 {
+		return nil
+	}
+	if newLines == nil {
+		newLines = []gamelog.Line{}
+	}
+	wailsRuntime.EventsEmit(appCommon.AppContext, "logTail", LogTailUpdate{
+		Install: t.install,
+		Reset:   reset,
+		Lines:   newLines,
+	})
+	return nil
+}
+
+// readNew returns the bytes written to the log since the last poll,
+// and whether the log was replaced, in which case the bytes are the whole new log
+func (t *logTail) readNew() ([]byte, bool, error) {
+	if t.local {
+		return t.readNewLocal()
+	}
+	if t.dialFTP != nil {
+		return t.readNewFTP()
+	}
+
+	exists, err := t.d.Exists(t.logPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check if log exists: %w", err)
+	}
+	if !exists {
+		return nil, t.offset > 0, nil
+	}
+	// The disk can only read whole files, so the log is only downloaded again once its size changed
+	size, ok, err := t.remoteLogSize()
+	if err != nil {
+		return nil, false, err
+	}
+	if ok && size == t.offset {
+		return nil, false, nil
+	}
+	data, err := t.d.Read(t.logPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read log: %w", err)
+	}
+	// A remote log can only be detected as replaced when it is shorter than what was already read
+	if int64(len(data)) < t.offset {
+		return data, true, nil
+	}
+	return data[t.offset:], false, nil
+}
+
+// remoteLogSize returns the size of the log from its directory listing, if the disk entries have it
+func (t *logTail) remoteLogSize() (int64, bool, error) {
+	entries, err := t.d.ReadDir(filepath.Dir(t.logPath))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to list logs: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(t.logPath) {
+			continue
+		}
+		if info, ok := entry.(fs.FileInfo); ok {
+			return info.Size(), true, nil
+		}
+		return 0, false, nil
+	}
+	return 0, false, nil
+}
+
+func (t *logTail) readNewFTP() ([]byte, bool, error) {
+	if t.ftpConn == nil {
+		conn, err := t.dialFTP()
+		if err != nil {
+			return nil, false, err
+		}
+		t.ftpConn = conn
+	}
+	data, reset, err := t.readNewFTPConn(t.ftpConn)
+	if err != nil {
+		// The server might have closed the idle connection, so the next poll connects again
+		t.closeFTP()
+	}
+	return data, reset, err
+}
+
+func (t *logTail) readNewFTPConn(conn *ftp.ServerConn) ([]byte, bool, error) {
+	logPath := filepath.ToSlash(t.logPath)
+	size, err := conn.FileSize(logPath)
+	if err != nil {
+		var protocolErr *textproto.Error
+		if errors.As(err, &protocolErr) && protocolErr.Code == ftp.StatusFileUnavailable {
+			return nil, t.offset > 0, nil
+		}
+		return nil, false, fmt.Errorf("failed to get log size: %w", err)
+	}
+
+	reset := size < t.offset
+	offset := t.offset
+	if reset {
+		offset = 0
+	}
+	if size == offset {
+		return nil, reset, nil
+	}
+	response, err := conn.RetrFrom(logPath, uint64(offset))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read log: %w", err)
+	}
+	defer response.Close()
+	data, err := io.ReadAll(io.LimitReader(response, size-offset))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read log: %w", err)
+	}
+	return data, reset, nil
+}
+
+func (t *logTail) closeFTP() {
+	if t.ftpConn == nil {
+		return
+	}
+	_ = t.ftpConn.Quit()
+	t.ftpConn = nil
+}
+
+func (t *logTail) readNewLocal() ([]byte, bool, error) {
+	file, err := os.Open(t.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, t.offset > 0, nil
+		}
+		return nil, false, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stat log: %w", err)
+	}
+	reset := info.Size() < t.offset || (t.localFile != nil && !os.SameFile(t.localFile, info))
+	t.localFile = info
+
+	offset := t.offset
+	if reset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil, reset, nil
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to seek log: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, info.Size()-offset))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read log: %w", err)
+	}
+	return data, reset, nil
+}
+
+func (t *logTail) setLevel(minLevel gamelog.Level) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.minLevel = minLevel
+}
+
+func (t *logTail) search(query string) []gamelog.Line {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	query = strings.ToLower(query)
+	result := []gamelog.Line{}
+	for _, line := range t.lines {
+		if !line.Level.AtLeast(t.minLevel) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(line.Text), query) {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
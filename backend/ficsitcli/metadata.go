@@ -177,7 +177,12 @@ func (f *ficsitCLI) getRemoteServerMetadata(installation *cli.Installation) (*co
 	remoteName := settings.Settings.RemoteNames[remoteKey(installation.Path)]
 
 	if remoteName == "" {
-		remoteName = f.GetNextRemoteLauncherName()
+		// Keep the generated name once the install has one, since generating it again would skip its own number
+		if previous, ok := f.installationMetadata.Load(installation.Path); ok && previous.Info != nil && previous.Info.Launcher != "" {
+			remoteName = previous.Info.Launcher
+		} else {
+			remoteName = f.GetNextRemoteLauncherName()
+		}
 	}
 
 	return &common.Installation{
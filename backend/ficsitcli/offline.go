@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
-	"github.com/satisfactorymodding/ficsit-cli/cli/provider"
 	resolver "github.com/satisfactorymodding/ficsit-resolver"
 
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
@@ -18,49 +17,43 @@ func (f *ficsitCLI) GetOffline() bool {
 	return f.ficsitCli.Provider.IsOffline()
 }
 
+// SetOffline sets the user's offline mode preference.
+// The app stays offline regardless while the API is unreachable
 func (f *ficsitCLI) SetOffline(offline bool) {
-	f.ficsitCli.Provider.(*provider.MixedProvider).Offline = offline
+	f.connectivity.lock.Lock()
+	defer f.connectivity.lock.Unlock()
 	settings.Settings.Offline = offline
 	_ = settings.SaveSettings()
+	f.updateEffectiveOffline()
+}
+
+func (f *ficsitCLI) GetOfflinePreference() bool {
+	return settings.Settings.Offline
 }
 
 type Mod struct {
-	ModReference string                `json:"mod_reference"`
-	Name         string                `json:"name"`
-	Logo         *string               `json:"logo"` // Base64 encoded
-	Authors      []string              `json:"authors"`
-	Versions     []resolver.ModVersion `json:"versions"`
+	ModReference  string                `json:"mod_reference"`
+	Name          string                `json:"name"`
+	Logo          *string               `json:"logo"` // Base64 encoded
+	Authors       []string              `json:"authors"`
+	Versions      []resolver.ModVersion `json:"versions"`
+	LatestVersion string                `json:"latest_version,omitempty"`
 }
 
+// OfflineGetMods lists all the cached mods, without their versions
 func (f *ficsitCLI) OfflineGetMods() ([]Mod, error) {
-	cache, err := ficsitcache.GetCacheMods()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cache: %w", err)
-	}
-
-	mods := make([]Mod, 0)
-	cache.Range(func(_ string, mod ficsitcache.Mod) bool {
-		mods = append(mods, f.convertCacheFileToMod(mod))
-		return true
-	})
-	return mods, nil
+	return getOfflineCatalogMods()
 }
 
+// OfflineGetModsByReferences lists the cached mods with the given references, without their versions
 func (f *ficsitCLI) OfflineGetModsByReferences(modReferences []string) ([]Mod, error) {
-	cache, err := ficsitcache.GetCacheMods()
+	mods, err := getOfflineCatalogMods()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cache: %w", err)
+		return nil, err
 	}
-
-	mods := make([]Mod, 0)
-	cache.Range(func(modReference string, mod ficsitcache.Mod) bool {
-		if !slices.Contains(modReferences, modReference) {
-			return true
-		}
-		mods = append(mods, f.convertCacheFileToMod(mod))
-		return true
-	})
-	return mods, nil
+	return slices.DeleteFunc(mods, func(mod Mod) bool {
+		return !slices.Contains(modReferences, mod.ModReference)
+	}), nil
 }
 
 func (f *ficsitCLI) OfflineGetMod(modReference string) (Mod, error) {
@@ -88,10 +81,11 @@ func (f *ficsitCLI) convertCacheFileToMod(mod ficsitcache.Mod) Mod {
 	}
 
 	return Mod{
-		Name:         mod.Name,
-		ModReference: mod.ModReference,
-		Authors:      authors,
-		Logo:         mod.Icon,
-		Versions:     modVersions,
+		Name:          mod.Name,
+		ModReference:  mod.ModReference,
+		Authors:       authors,
+		Logo:          mod.Icon,
+		Versions:      modVersions,
+		LatestVersion: mod.LatestVersion,
 	}
 }
@@ -0,0 +1,263 @@
+package ficsitcli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
+	"github.com/satisfactorymodding/ficsit-cli/cli/provider"
+	ficsitUtils "github.com/satisfactorymodding/ficsit-cli/utils"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type PrefetchFailure struct {
+	Item    string `json:"item"`
+	Version string `json:"version"`
+	Target  string `json:"target"`
+	Error   string `json:"error"`
+}
+
+type OfflineTargetCoverage struct {
+	Target  string `json:"target"`
+	Covered bool   `json:"covered"`
+	// Missing are the mods whose archive for this target is not in the cache
+	Missing []string `json:"missing"`
+	// Error is set when the profile cannot be resolved offline for this target
+	Error *string `json:"error"`
+}
+
+type OfflinePrefetchResult struct {
+	Profile    string                  `json:"profile"`
+	Downloaded int                     `json:"downloaded"`
+	Failed     []PrefetchFailure       `json:"failed"`
+	Targets    []OfflineTargetCoverage `json:"targets"`
+	// MissingMetadata are the mods that the offline mod list would not show
+	MissingMetadata []string `json:"missingMetadata"`
+}
+
+// PrefetchProfile downloads everything needed to use a profile in offline mode,
+// then checks that the profile resolves with only the offline data, for every target it requires
+func (f *ficsitCLI) PrefetchProfile(profileName string) (*OfflinePrefetchResult, error) {
+	var result *OfflinePrefetchResult
+	err := f.action(ActionPrefetch, newSimpleItem(profileName), func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		defer close(taskChannel)
+
+		if f.GetOffline() {
+			return fmt.Errorf("cannot make a profile available offline while in offline mode")
+		}
+
+		profile := f.GetProfile(profileName)
+		if profile == nil {
+			return fmt.Errorf("profile %s not found", profileName)
+		}
+
+		lockfile, err := f.getProfileLockfile(profileName)
+		if err != nil {
+			l.Error("failed to get lockfile", slog.Any("error", err))
+			return fmt.Errorf("failed to get lockfile: %w", err)
+		}
+		if lockfile == nil {
+			return fmt.Errorf("profile %s has not been applied to any installation yet", profileName)
+		}
+
+		gameVersion, err := f.getProfileGameVersion(profileName)
+		if err != nil {
+			l.Error("failed to get game version", slog.Any("error", err))
+			return fmt.Errorf("failed to get game version: %w", err)
+		}
+
+		installTargets, err := f.getProfileInstallTargets(profileName)
+		if err != nil {
+			l.Error("failed to get install targets", slog.Any("error", err))
+			return fmt.Errorf("failed to get install targets: %w", err)
+		}
+		targets := mergeTargets(installTargets, f.GetProfileExtraTargets(profileName))
+
+		result = &OfflinePrefetchResult{
+			Profile:         profileName,
+			Failed:          []PrefetchFailure{},
+			Targets:         []OfflineTargetCoverage{},
+			MissingMetadata: []string{},
+		}
+
+		// The offline provider reads the versions from the local registry, which is filled when querying the versions online
+		for modReference := range lockfile.Mods {
+			_, err := f.ficsitCli.Provider.ModVersionsWithDependencies(context.TODO(), modReference)
+			if err != nil {
+				l.Warn("failed to get mod versions", slog.String("mod", modReference), slog.Any("error", err))
+			}
+		}
+
+		var failedLock sync.Mutex
+		var downloaded int
+		errg := errgroup.Group{}
+		errg.SetLimit(viper.GetInt("concurrent-downloads"))
+		for modReference, lockedMod := range lockfile.Mods {
+			for _, target := range targets {
+				lockedTarget, ok := lockedMod.Targets[string(target)]
+				// Same as the install, mods without this target are not needed on it, and mods without a link are assumed installed
+				if !ok || lockedTarget.Link == "" {
+					continue
+				}
+				errg.Go(func() error {
+					err := prefetchArchive(modReference, lockedMod.Version, string(target), lockedTarget, taskChannel)
+					failedLock.Lock()
+					defer failedLock.Unlock()
+					if err != nil {
+						l.Warn("failed to download mod", slog.String("mod", modReference), slog.String("version", lockedMod.Version), slog.String("target", string(target)), slog.Any("error", err))
+						result.Failed = append(result.Failed, PrefetchFailure{
+							Item:    modReference,
+							Version: lockedMod.Version,
+							Target:  string(target),
+							Error:   err.Error(),
+						})
+						return nil
+					}
+					downloaded++
+					return nil
+				})
+			}
+		}
+		_ = errg.Wait()
+		result.Downloaded = downloaded
+
+		// The offline mod list is built from the archives in the download cache
+		cacheMods, err := ficsitcache.LoadCacheMods()
+		if err != nil {
+			l.Error("failed to reload cache", slog.Any("error", err))
+			return fmt.Errorf("failed to reload cache: %w", err)
+		}
+		for modReference := range lockfile.Mods {
+			if _, ok := cacheMods.Load(modReference); !ok {
+				result.MissingMetadata = append(result.MissingMetadata, modReference)
+			}
+		}
+
+		for _, target := range targets {
+			result.Targets = append(result.Targets, f.checkOfflineCoverage(profile, lockfile, gameVersion, target))
+		}
+
+		slices.SortFunc(result.Failed, func(a, b PrefetchFailure) int {
+			return strings.Compare(a.Item+a.Target, b.Item+b.Target)
+		})
+		slices.Sort(result.MissingMetadata)
+
+		l.Info("prefetched profile", slog.Int("downloaded", result.Downloaded), slog.Int("failed", len(result.Failed)))
+		return nil
+	})
+	return result, err
+}
+
+func prefetchArchive(modReference string, version string, target string, lockedTarget resolver.LockedModTarget, taskChannel chan<- taskUpdate) error {
+	downloadUpdates := make(chan ficsitUtils.GenericProgress)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for update := range downloadUpdates {
+			taskChannel <- taskUpdate{
+				taskName: fmt.Sprintf("%s:%s:%s:download", modReference, version, target),
+				progress: utils.Progress{
+					Current: update.Completed,
+					Total:   update.Total,
+				},
+			}
+		}
+	}()
+	defer wg.Wait()
+	defer close(downloadUpdates)
+
+	file, _, err := ficsitcache.DownloadOrCache(modReference+"_"+version+"_"+target+".zip", lockedTarget.Hash, lockedTarget.Link, downloadUpdates, nil)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	return file.Close() //nolint:wrapcheck
+}
+
+// checkOfflineCoverage resolves the profile for a single target using only the offline data,
+// and checks that every archive the result needs is cached
+func (f *ficsitCLI) checkOfflineCoverage(profile *cli.Profile, lockfile *resolver.LockFile, gameVersion int, target resolver.TargetName) OfflineTargetCoverage {
+	coverage := OfflineTargetCoverage{
+		Target:  string(target),
+		Missing: []string{},
+	}
+
+	localMods := f.localMods()
+	localMods.modsLock.RLock()
+	offlineLocalModsProvider := &localModsProvider{
+		onlineProvider:  newRepositoryProvider(nil),
+		offlineProvider: provider.NewLocalProvider(),
+		mods:            maps.Clone(localMods.mods),
+	}
+	localMods.modsLock.RUnlock()
+	offlineLocalModsProvider.SetOffline(true)
+
+	offlineProfile := &cli.Profile{
+		Name:            profile.Name,
+		Mods:            profile.Mods,
+		RequiredTargets: []resolver.TargetName{target},
+	}
+	offlineLockfile, err := offlineProfile.Resolve(resolver.NewDependencyResolver(offlineLocalModsProvider), lockfile.Clone(), gameVersion)
+	if err != nil {
+		errString := err.Error()
+		coverage.Error = &errString
+		return coverage
+	}
+
+	cachedArchives, err := getCachedArchives()
+	if err != nil {
+		errString := err.Error()
+		coverage.Error = &errString
+		return coverage
+	}
+	cached := make(map[string]bool, len(cachedArchives))
+	for _, archive := range cachedArchives {
+		cached[archive.fileName] = true
+	}
+
+	for modReference, lockedMod := range offlineLockfile.Mods {
+		lockedTarget, ok := lockedMod.Targets[string(target)]
+		if !ok || lockedTarget.Link == "" {
+			continue
+		}
+		if !cached[modReference+"_"+lockedMod.Version+"_"+string(target)+".zip"] {
+			coverage.Missing = append(coverage.Missing, modReference)
+		}
+	}
+	slices.Sort(coverage.Missing)
+
+	coverage.Covered = len(coverage.Missing) == 0
+	return coverage
+}
+
+// getProfileGameVersion returns the game version of an install using the profile, preferring the selected install
+func (f *ficsitCLI) getProfileGameVersion(profileName string) (int, error) {
+	installs := make([]*cli.Installation, 0, len(f.ficsitCli.Installations.Installations))
+	if selectedInstallation := f.GetSelectedInstall(); selectedInstallation != nil {
+		installs = append(installs, selectedInstallation)
+	}
+	for _, installPath := range f.GetInstallations() {
+		installs = append(installs, f.GetInstallation(installPath))
+	}
+	for _, installation := range installs {
+		if installation.Profile != profileName {
+			continue
+		}
+		meta, ok := f.installationMetadata.Load(installation.Path)
+		if !ok || meta.State != InstallStateValid {
+			continue
+		}
+		return installation.GetGameVersion(f.ficsitCli) //nolint:wrapcheck
+	}
+	return 0, fmt.Errorf("no installation uses profile %s", profileName)
+}
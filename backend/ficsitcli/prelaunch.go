@@ -0,0 +1,221 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/exp/maps"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+)
+
+type LaunchIssue struct {
+	Kind LaunchIssueKind `json:"kind"`
+	// Mod is empty for issues that are not about a single mod
+	Mod string `json:"mod"`
+	// Expected is the version in the lockfile, or the game version required by the mod
+	Expected string `json:"expected"`
+	// Found is the version on disk, or the installed game version
+	Found string `json:"found"`
+}
+
+type LaunchCheck struct {
+	Install string        `json:"install"`
+	OK      bool          `json:"ok"`
+	Issues  []LaunchIssue `json:"issues"`
+}
+
+// CheckLaunch compares the mods in the install's Mods directory with its lockfile,
+// and checks that the installed mods support the game version.
+// Unlike VerifyInstall, it only reads the .uplugin files, so it is fast enough to run before every launch
+func (f *ficsitCLI) CheckLaunch(installPath string) (*LaunchCheck, error) {
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.State != InstallStateValid || meta.Info == nil {
+		return nil, fmt.Errorf("installation %s is not valid", installPath)
+	}
+
+	check := &LaunchCheck{
+		Install: installPath,
+		Issues:  []LaunchIssue{},
+	}
+
+	installed, err := getInstalledModPlugins(installation)
+	if err != nil {
+		return nil, err
+	}
+	installedReferences := maps.Keys(installed)
+	slices.Sort(installedReferences)
+
+	if installation.Vanilla {
+		// Disabling mods removes them, so any mod left behind would still be loaded
+		for _, modReference := range installedReferences {
+			check.Issues = append(check.Issues, LaunchIssue{
+				Kind:  LaunchIssueKindModsWhileDisabled,
+				Mod:   modReference,
+				Found: installed[modReference].SemVersion,
+			})
+		}
+		check.OK = len(check.Issues) == 0
+		return check, nil
+	}
+
+	lockfile, err := installation.LockFile(f.ficsitCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	platform, err := installation.GetPlatform(f.ficsitCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get platform: %w", err)
+	}
+
+	lockedReferences := []string{}
+	if lockfile != nil {
+		for modReference, lockedMod := range lockfile.Mods {
+			// Same as the install, mods without this target are not installed
+			if _, ok := lockedMod.Targets[platform.TargetName]; ok {
+				lockedReferences = append(lockedReferences, modReference)
+			}
+		}
+	}
+	slices.Sort(lockedReferences)
+
+	if len(lockedReferences) > 0 && installed["SML"] == nil {
+		issue := LaunchIssue{Kind: LaunchIssueKindMissingSML, Mod: "SML"}
+		if lockedSML, ok := lockfile.Mods["SML"]; ok {
+			issue.Expected = lockedSML.Version
+		}
+		check.Issues = append(check.Issues, issue)
+	}
+
+	for _, modReference := range lockedReferences {
+		lockedMod := lockfile.Mods[modReference]
+		uplugin, ok := installed[modReference]
+		if !ok {
+			if modReference != "SML" {
+				check.Issues = append(check.Issues, LaunchIssue{Kind: LaunchIssueKindMissingMod, Mod: modReference, Expected: lockedMod.Version})
+			}
+			continue
+		}
+		if strings.TrimPrefix(uplugin.SemVersion, "v") != strings.TrimPrefix(lockedMod.Version, "v") {
+			check.Issues = append(check.Issues, LaunchIssue{Kind: LaunchIssueKindWrongVersion, Mod: modReference, Expected: lockedMod.Version, Found: uplugin.SemVersion})
+		}
+	}
+
+	for _, modReference := range installedReferences {
+		if !slices.Contains(lockedReferences, modReference) {
+			check.Issues = append(check.Issues, LaunchIssue{Kind: LaunchIssueKindExtraMod, Mod: modReference, Found: installed[modReference].SemVersion})
+		}
+	}
+
+	// The game version constraints are parsed the same way the resolver does
+	gameVersion, err := semver.NewVersion(fmt.Sprintf("%d", meta.Info.Version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse game version: %w", err)
+	}
+	for _, modReference := range installedReferences {
+		uplugin := installed[modReference]
+		if uplugin.GameVersion == "" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(uplugin.GameVersion)
+		if err != nil {
+			slog.Warn("ignoring invalid game version constraint", slog.String("mod", modReference), slog.String("gameVersion", uplugin.GameVersion), slog.Any("error", err))
+			continue
+		}
+		if !constraint.Check(gameVersion) {
+			check.Issues = append(check.Issues, LaunchIssue{Kind: LaunchIssueKindIncompatibleGameVersion, Mod: modReference, Expected: uplugin.GameVersion, Found: fmt.Sprintf("%d", meta.Info.Version)})
+		}
+	}
+
+	check.OK = len(check.Issues) == 0
+	return check, nil
+}
+
+// FixAndLaunchGame removes the mods that do not match the lockfile, applies the profile again, then launches the game
+func (f *ficsitCLI) FixAndLaunchGame() error {
+	selectedInstallation := f.GetSelectedInstall()
+	if selectedInstallation == nil {
+		return fmt.Errorf("no installation selected")
+	}
+	profileName := f.GetSelectedProfile()
+	if profileName == nil {
+		return fmt.Errorf("no profile selected")
+	}
+
+	err := f.action(ActionRepair, newSimpleItem(selectedInstallation.Path), func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		check, err := f.CheckLaunch(selectedInstallation.Path)
+		if err != nil {
+			close(taskChannel)
+			l.Error("failed to check install", slog.Any("error", err))
+			return err
+		}
+
+		err = f.checkGameNotRunning(selectedInstallation.Path)
+		if err != nil {
+			close(taskChannel)
+			return err
+		}
+
+		d, err := selectedInstallation.GetDisk()
+		if err != nil {
+			close(taskChannel)
+			l.Error("failed to get disk", slog.Any("error", err))
+			return fmt.Errorf("failed to get disk: %w", err)
+		}
+		modsDirectory := filepath.Join(selectedInstallation.BasePath(), "FactoryGame", "Mods")
+
+		for _, issue := range check.Issues {
+			switch issue.Kind {
+			case LaunchIssueKindExtraMod, LaunchIssueKindWrongVersion, LaunchIssueKindModsWhileDisabled:
+				// Removing the whole directory also removes the .smm hash file, so the apply extracts it again if needed
+				l.Info("removing mod that does not match the lockfile", slog.String("mod", issue.Mod), slog.String("issue", string(issue.Kind)))
+				err := d.Remove(filepath.Join(modsDirectory, issue.Mod))
+				if err != nil {
+					close(taskChannel)
+					l.Error("failed to remove mod", slog.String("mod", issue.Mod), slog.Any("error", err))
+					return fmt.Errorf("failed to remove mod %s: %w", issue.Mod, err)
+				}
+			default:
+			}
+		}
+
+		// Installs the missing mods, and updates the lockfile if the game version changed
+		return f.apply(l, taskChannel)
+	})
+	if err != nil {
+		return err
+	}
+
+	f.launchGame(false)
+	return nil
+}
+
+// LaunchGameAnyway launches the game without the pre-launch check
+func (f *ficsitCLI) LaunchGameAnyway() {
+	f.launchGame(false)
+}
+
+// runPreLaunchCheck returns false if the launch must be stopped, after notifying the frontend of the issues
+func (f *ficsitCLI) runPreLaunchCheck(installPath string) bool {
+	check, err := f.CheckLaunch(installPath)
+	if err != nil {
+		// The check is only a safety net, it must not prevent launching
+		slog.Warn("failed to run pre-launch check", slog.Any("error", err))
+		return true
+	}
+	if check.OK {
+		return true
+	}
+	slog.Info("pre-launch check failed", slog.String("install", installPath), slog.Int("issues", len(check.Issues)))
+	wailsRuntime.EventsEmit(appCommon.AppContext, "launchCheckFailed", check)
+	return false
+}
@@ -0,0 +1,235 @@
+package ficsitcli
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	"github.com/spf13/viper"
+)
+
+// Mod configs are written to the Configs directory of the install's Saved directory,
+// and are read and written through the install's disk, which also works for remote servers
+func (f *ficsitCLI) installConfigsPath(installation *cli.Installation) (string, error) {
+	meta, ok := f.installationMetadata.Load(installation.Path)
+	if !ok || meta.Info == nil {
+		return "", fmt.Errorf("installation %s is not loaded", installation.Path)
+	}
+	return filepath.Join(meta.Info.SavedPath, "Configs"), nil
+}
+
+func profileConfigsSnapshotPath(installPath string, profileName string) string {
+	return filepath.Join(viper.GetString("smm-local-dir"), "profileConfigs", remoteKey(installPath), remoteKey(profileName)+".zip")
+}
+
+// switchProfileConfigs saves the configs of the profile being left, and restores the ones of the new profile, if any were saved.
+// Failures are only logged, since they must not prevent switching the profile
+func (f *ficsitCLI) switchProfileConfigs(l *slog.Logger, installation *cli.Installation, oldProfile string, newProfile string) {
+	err := f.snapshotProfileConfigs(installation, oldProfile)
+	if err != nil {
+		// Restoring would overwrite the configs that could not be saved
+		l.Error("failed to save profile configs, not restoring the new profile's configs", slog.String("profile", oldProfile), slog.Any("error", err))
+		return
+	}
+
+	bundle, err := os.ReadFile(profileConfigsSnapshotPath(installation.Path, newProfile))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			l.Error("failed to read profile configs", slog.String("profile", newProfile), slog.Any("error", err))
+		}
+		return
+	}
+
+	err = f.writeConfigsBundle(installation, bundle)
+	if err != nil {
+		l.Error("failed to restore profile configs", slog.String("profile", newProfile), slog.Any("error", err))
+	}
+}
+
+func (f *ficsitCLI) snapshotProfileConfigs(installation *cli.Installation, profileName string) error {
+	bundle, err := f.readConfigsBundle(installation)
+	if err != nil {
+		return err
+	}
+
+	snapshotPath := profileConfigsSnapshotPath(installation.Path, profileName)
+	err = os.MkdirAll(filepath.Dir(snapshotPath), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create profile configs directory: %w", err)
+	}
+	err = os.WriteFile(snapshotPath, bundle, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write profile configs: %w", err)
+	}
+	return nil
+}
+
+// ExportProfileConfigs writes the profile's mod configs for the selected install to a zip file.
+// For the active profile the current configs are exported, otherwise the ones saved when leaving it
+func (f *ficsitCLI) ExportProfileConfigs(profileName string, filename string) error {
+	l := slog.With(slog.String("task", "exportProfileConfigs"), slog.String("profile", profileName), slog.String("file", filename))
+
+	installation := f.GetSelectedInstall()
+	if installation == nil {
+		return fmt.Errorf("no installation selected")
+	}
+	if f.GetProfile(profileName) == nil {
+		return fmt.Errorf("profile %s not found", profileName)
+	}
+
+	var bundle []byte
+	var err error
+	if installation.Profile == profileName {
+		bundle, err = f.readConfigsBundle(installation)
+	} else {
+		bundle, err = os.ReadFile(profileConfigsSnapshotPath(installation.Path, profileName))
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no configs saved for profile %s on this installation", profileName)
+		}
+	}
+	if err != nil {
+		l.Error("failed to read profile configs", slog.Any("error", err))
+		return fmt.Errorf("failed to read profile configs: %w", err)
+	}
+
+	err = os.WriteFile(filename, bundle, 0o755)
+	if err != nil {
+		l.Error("failed to write profile configs", slog.Any("error", err))
+		return fmt.Errorf("failed to write profile configs: %w", err)
+	}
+	return nil
+}
+
+// ImportProfileConfigs replaces the profile's mod configs for the selected install with the ones in the zip file.
+// For the active profile they are written to the install immediately, otherwise they are restored when switching to it
+func (f *ficsitCLI) ImportProfileConfigs(profileName string, filename string) error {
+	return f.action(ActionImportConfigs, newSimpleItem(profileName), func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		defer close(taskChannel)
+
+		l = l.With(slog.String("file", filename))
+
+		installation := f.GetSelectedInstall()
+		if installation == nil {
+			return fmt.Errorf("no installation selected")
+		}
+		if f.GetProfile(profileName) == nil {
+			return fmt.Errorf("profile %s not found", profileName)
+		}
+
+		bundle, err := os.ReadFile(filename)
+		if err != nil {
+			l.Error("failed to read configs file", slog.Any("error", err))
+			return fmt.Errorf("failed to read configs file: %w", err)
+		}
+		_, err = zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+		if err != nil {
+			return fmt.Errorf("invalid configs file: %w", err)
+		}
+
+		if installation.Profile == profileName {
+			err = f.checkGameNotRunning(installation.Path)
+			if err != nil {
+				return err
+			}
+			err = f.writeConfigsBundle(installation, bundle)
+			if err != nil {
+				l.Error("failed to write configs", slog.Any("error", err))
+				return fmt.Errorf("failed to write configs: %w", err)
+			}
+			return nil
+		}
+
+		snapshotPath := profileConfigsSnapshotPath(installation.Path, profileName)
+		err = os.MkdirAll(filepath.Dir(snapshotPath), 0o755)
+		if err != nil {
+			return fmt.Errorf("failed to create profile configs directory: %w", err)
+		}
+		err = os.WriteFile(snapshotPath, bundle, 0o755)
+		if err != nil {
+			l.Error("failed to save configs", slog.Any("error", err))
+			return fmt.Errorf("failed to save configs: %w", err)
+		}
+		return nil
+	})
+}
+
+func (f *ficsitCLI) renameProfileConfigs(oldName string, newName string) {
+	for _, installPath := range f.GetInstallations() {
+		oldPath := profileConfigsSnapshotPath(installPath, oldName)
+		if _, err := os.Stat(oldPath); err != nil {
+			continue
+		}
+		err := os.Rename(oldPath, profileConfigsSnapshotPath(installPath, newName))
+		if err != nil {
+			slog.Error("failed to rename profile configs", slog.String("install", installPath), slog.Any("error", err))
+		}
+	}
+}
+
+func (f *ficsitCLI) deleteProfileConfigs(name string) {
+	for _, installPath := range f.GetInstallations() {
+		err := os.Remove(profileConfigsSnapshotPath(installPath, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Error("failed to delete profile configs", slog.String("install", installPath), slog.Any("error", err))
+		}
+	}
+}
+
+// readConfigsBundle zips the install's configs directory
+func (f *ficsitCLI) readConfigsBundle(installation *cli.Installation) ([]byte, error) {
+	d, err := installation.GetDisk()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	configsPath, err := f.installConfigsPath(installation)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = zipDiskDirectory(&buf, d, configsPath, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to zip configs: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeConfigsBundle replaces the install's configs directory with the contents of the bundle
+func (f *ficsitCLI) writeConfigsBundle(installation *cli.Installation, bundle []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return fmt.Errorf("failed to read configs bundle: %w", err)
+	}
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		return fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	configsPath, err := f.installConfigsPath(installation)
+	if err != nil {
+		return err
+	}
+	exists, err := d.Exists(configsPath)
+	if err != nil {
+		return fmt.Errorf("failed to check configs directory: %w", err)
+	}
+	if exists {
+		err = d.Remove(configsPath)
+		if err != nil {
+			return fmt.Errorf("failed to remove configs directory: %w", err)
+		}
+	}
+
+	err = unzipToDisk(reader, d, configsPath)
+	if err != nil {
+		return fmt.Errorf("failed to write configs: %w", err)
+	}
+	return nil
+}
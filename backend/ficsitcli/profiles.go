@@ -31,12 +31,22 @@ func (f *ficsitCLI) SetProfile(profile string) error {
 			return nil
 		}
 
-		err := selectedInstallation.SetProfile(f.ficsitCli, profile)
+		// The configs of the install are swapped for the ones of the new profile
+		err := f.checkGameNotRunning(selectedInstallation.Path)
+		if err != nil {
+			return err
+		}
+
+		oldProfile := selectedInstallation.Profile
+
+		err = selectedInstallation.SetProfile(f.ficsitCli, profile)
 		if err != nil {
 			l.Error("failed to set profile", slog.Any("error", err))
 			return fmt.Errorf("failed to set profile: %w", err)
 		}
 
+		f.switchProfileConfigs(l, selectedInstallation, oldProfile, profile)
+
 		err = f.ficsitCli.Installations.Save()
 		if err != nil {
 			l.Error("failed to save installations", slog.Any("error", err))
@@ -144,6 +154,11 @@ func (f *ficsitCLI) RenameProfile(oldName string, newName string) error {
 		l.Error("failed to save installations", slog.Any("error", err))
 	}
 
+	f.renameProfileExtraTargets(oldName, newName)
+	f.renameProfileConfigs(oldName, newName)
+	f.renameSaveProfiles(oldName, newName)
+	f.renameProfileLaunchOptions(oldName, newName)
+
 	f.EmitGlobals()
 
 	return nil
@@ -177,6 +192,10 @@ func (f *ficsitCLI) DeleteProfile(name string) error {
 		l.Error("failed to save installations", slog.Any("error", err))
 	}
 
+	f.deleteProfileExtraTargets(name)
+	f.deleteProfileConfigs(name)
+	f.deleteProfileLaunchOptions(name)
+
 	f.EmitGlobals()
 
 	return nil
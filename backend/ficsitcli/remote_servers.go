@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/credentials"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
 )
@@ -24,15 +25,39 @@ func (f *ficsitCLI) GetRemoteInstallations() []string {
 }
 
 func (f *ficsitCLI) AddRemoteServer(path string, name string) error {
-	if f.ficsitCli.Installations.GetInstallation(path) != nil {
+	// The credentials are kept in the credential store, so that they are not saved in installations.json
+	cleanPath, credential, err := splitCredentials(path)
+	if err != nil {
+		return err
+	}
+	if f.ficsitCli.Installations.GetInstallation(cleanPath) != nil {
 		return fmt.Errorf("installation already exists")
 	}
-	l := slog.With(slog.String("task", "addRemoteServer"), slog.String("path", path))
+	if credential != nil && f.credentials.Locked() {
+		return credentials.ErrLocked
+	}
+	l := slog.With(slog.String("task", "addRemoteServer"), slog.String("path", cleanPath))
 
 	installation, err := f.ficsitCli.Installations.AddInstallation(f.ficsitCli, path, f.GetFallbackProfile())
 	if err != nil {
 		return fmt.Errorf("failed to add installation: %w", err)
 	}
+	installation.Path = cleanPath
+	// The disk created while adding the installation is already connected with the credentials
+	installation.DiskInstance = &remoteDisk{f: f, path: cleanPath, inner: installation.DiskInstance, connected: true}
+
+	if credential != nil {
+		err = f.credentials.Set(remoteKey(cleanPath), *credential)
+		if err != nil {
+			l.Error("failed to store credentials", slog.Any("error", err))
+			// Saved without its credentials, the installation could not connect after a restart
+			removeErr := f.ficsitCli.Installations.DeleteInstallation(cleanPath)
+			if removeErr != nil {
+				l.Error("failed to remove installation", slog.Any("error", removeErr))
+			}
+			return fmt.Errorf("failed to store credentials: %w", err)
+		}
+	}
 
 	err = f.ficsitCli.Installations.Save()
 	if err != nil {
@@ -53,7 +78,7 @@ func (f *ficsitCLI) AddRemoteServer(path string, name string) error {
 
 	_ = settings.SaveSettings()
 
-	f.installationMetadata.Store(path, installationMetadata{
+	f.installationMetadata.Store(installation.Path, installationMetadata{
 		State: InstallStateValid,
 		Info:  meta,
 	})
@@ -87,6 +112,13 @@ func (f *ficsitCLI) RemoveRemoteServer(path string) error {
 	delete(settings.Settings.RemoteNames, remoteKey(path))
 	_ = settings.SaveSettings()
 
+	if !f.credentials.Locked() {
+		err = f.credentials.Delete(remoteKey(path))
+		if err != nil {
+			slog.Error("failed to delete credentials", slog.Any("error", err))
+		}
+	}
+
 	f.EmitGlobals()
 
 	return nil
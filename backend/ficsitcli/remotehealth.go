@@ -0,0 +1,184 @@
+package ficsitcli
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type RemoteServerHealth struct {
+	Install string `json:"install"`
+	Healthy bool   `json:"healthy"`
+	// Latency is the time the last successful check took, in milliseconds
+	Latency   *int64     `json:"latency"`
+	LastSeen  *time.Time `json:"lastSeen"`
+	LastCheck *time.Time `json:"lastCheck"`
+	Error     *string    `json:"error"`
+}
+
+type RemoteServerStateChange struct {
+	Install string             `json:"install"`
+	From    InstallState       `json:"from"`
+	To      InstallState       `json:"to"`
+	Health  RemoteServerHealth `json:"health"`
+}
+
+type remoteHealth struct {
+	servers map[string]*RemoteServerHealth
+	// checking prevents starting a new check of a server while a timed out one is still running
+	checking map[string]bool
+	lock     sync.Mutex
+}
+
+// StartRemoteServerMonitor periodically checks every remote server, so that servers that were down are loaded
+// once they are back, and servers that go down are noticed
+func (f *ficsitCLI) StartRemoteServerMonitor() {
+	go func() {
+		for {
+			// Read every time, so that changes to the interval apply without a restart
+			time.Sleep(time.Duration(settings.Settings.GetRemoteHealthCheckInterval()) * time.Second)
+			f.checkRemoteServers()
+		}
+	}()
+}
+
+// GetRemoteServerHealth returns the result of the last check of each remote server
+func (f *ficsitCLI) GetRemoteServerHealth() map[string]RemoteServerHealth {
+	f.remoteHealth.lock.Lock()
+	defer f.remoteHealth.lock.Unlock()
+	result := make(map[string]RemoteServerHealth, len(f.remoteHealth.servers))
+	for install, health := range f.remoteHealth.servers {
+		result[install] = *health
+	}
+	return result
+}
+
+// CheckRemoteServer checks a remote server now, instead of waiting for the next periodic check
+func (f *ficsitCLI) CheckRemoteServer(path string) (*RemoteServerHealth, error) {
+	installation := f.GetInstallation(path)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", path)
+	}
+	if meta, ok := f.installationMetadata.Load(path); ok && meta.Info != nil && meta.Info.Location != common.LocationTypeRemote {
+		return nil, fmt.Errorf("installation %s is not remote", path)
+	}
+	return f.checkRemoteServer(installation), nil
+}
+
+func (f *ficsitCLI) checkRemoteServers() {
+	var wg sync.WaitGroup
+	for _, path := range f.GetRemoteInstallations() {
+		meta, ok := f.installationMetadata.Load(path)
+		// Loading installs are already being checked, and invalid ones are not servers
+		if ok && (meta.State == InstallStateLoading || meta.State == InstallStateInvalid) {
+			continue
+		}
+		installation := f.GetInstallation(path)
+		if installation == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.checkRemoteServer(installation)
+		}()
+	}
+	wg.Wait()
+}
+
+func (f *ficsitCLI) checkRemoteServer(installation *cli.Installation) *RemoteServerHealth {
+	path := installation.Path
+
+	f.remoteHealth.lock.Lock()
+	health, ok := f.remoteHealth.servers[path]
+	if !ok {
+		health = &RemoteServerHealth{Install: path}
+		f.remoteHealth.servers[path] = health
+	}
+	if f.remoteHealth.checking[path] {
+		result := *health
+		f.remoteHealth.lock.Unlock()
+		return &result
+	}
+	f.remoteHealth.checking[path] = true
+	f.remoteHealth.lock.Unlock()
+
+	type fetchResult struct {
+		meta *common.Installation
+		err  error
+	}
+	resultChannel := make(chan fetchResult, 1)
+	start := time.Now()
+	go func() {
+		meta, err := f.getRemoteServerMetadata(installation)
+		resultChannel <- fetchResult{meta, err}
+
+		f.remoteHealth.lock.Lock()
+		delete(f.remoteHealth.checking, path)
+		f.remoteHealth.lock.Unlock()
+	}()
+
+	timeout := time.Duration(settings.Settings.GetRemoteHealthCheckTimeout()) * time.Second
+	var result fetchResult
+	select {
+	case result = <-resultChannel:
+	case <-time.After(timeout):
+		result.err = fmt.Errorf("server did not respond in %s", timeout)
+	}
+	now := time.Now()
+
+	previous, _ := f.installationMetadata.Load(path)
+	next := previous
+	switch {
+	case result.err == nil:
+		next = installationMetadata{State: InstallStateValid, Info: result.meta}
+	case errors.Is(result.err, ErrInstallNotServer):
+		next = installationMetadata{State: InstallStateInvalid}
+	default:
+		// The last known info is kept, so the server is still shown with its name while it is down
+		next = installationMetadata{State: InstallStateUnknown, Info: previous.Info}
+	}
+	f.installationMetadata.Store(path, next)
+
+	f.remoteHealth.lock.Lock()
+	health.LastCheck = &now
+	health.Healthy = result.err == nil
+	if result.err == nil {
+		latency := now.Sub(start).Milliseconds()
+		health.Latency = &latency
+		health.LastSeen = &now
+		health.Error = nil
+	} else {
+		errString := result.err.Error()
+		health.Error = &errString
+	}
+	healthCopy := *health
+	f.remoteHealth.lock.Unlock()
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "remoteServerHealth", healthCopy)
+
+	if previous.State != next.State {
+		slog.Info("remote server state changed", slog.String("path", path), slog.String("from", string(previous.State)), slog.String("to", string(next.State)), slog.Any("error", result.err))
+		wailsRuntime.EventsEmit(appCommon.AppContext, "remoteServerStateChanged", RemoteServerStateChange{
+			Install: path,
+			From:    previous.State,
+			To:      next.State,
+			Health:  healthCopy,
+		})
+		if next.State == InstallStateInvalid && path == f.ficsitCli.Installations.SelectedInstallation {
+			f.ensureSelectedInstallationIsValid()
+		}
+		f.EmitGlobals()
+	}
+
+	return &healthCopy
+}
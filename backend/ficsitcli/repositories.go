@@ -0,0 +1,327 @@
+package ficsitcli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/satisfactorymodding/ficsit-cli/cli/localregistry"
+	"github.com/satisfactorymodding/ficsit-cli/cli/provider"
+	"github.com/satisfactorymodding/ficsit-cli/ficsit"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type RepositoryEndpointHealth struct {
+	APIBase   string    `json:"apiBase"`
+	Healthy   bool      `json:"healthy"`
+	Latency   int64     `json:"latency"` // milliseconds
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// repositoryProvider queries the repository endpoints from the settings in order,
+// falling back to the next endpoint when one fails or does not have the requested mod
+type repositoryProvider struct {
+	httpClient *http.Client
+	// onUnreachable is called when every endpoint failed to respond
+	onUnreachable func(err error)
+}
+
+var _ provider.Provider = repositoryProvider{}
+
+func newRepositoryProvider(onUnreachable func(err error)) repositoryProvider {
+	return repositoryProvider{
+		onUnreachable: onUnreachable,
+		httpClient: &http.Client{
+			Transport: &ficsit.AuthedTransport{
+				Wrapped: serverErrorTransport{Wrapped: http.DefaultTransport},
+			},
+		},
+	}
+}
+
+// serverErrorTransport fails requests that get a 5xx response, so that an API that is up but failing,
+// like a gateway answering 502 while ficsit.app is down, is handled the same as an unreachable one
+type serverErrorTransport struct {
+	Wrapped http.RoundTripper
+}
+
+func (t serverErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := t.Wrapped.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if response.StatusCode >= http.StatusInternalServerError {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("server error: %s", response.Status)
+	}
+	return response, nil
+}
+
+func (p repositoryProvider) client(endpoint settings.RepositoryEndpoint) graphql.Client {
+	return graphql.NewClient(endpoint.APIBase+endpoint.GraphQLAPI, p.httpClient)
+}
+
+func (p repositoryProvider) Mods(ctx context.Context, filter ficsit.ModFilter) (*ficsit.ModsResponse, error) {
+	var errs []error
+	for _, endpoint := range settings.Settings.GetRepositoryEndpoints() {
+		response, err := ficsit.Mods(ctx, p.client(endpoint), filter)
+		if err == nil {
+			return response, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.APIBase, err))
+	}
+	return nil, p.endpointsFailed(errs)
+}
+
+func (p repositoryProvider) GetMod(ctx context.Context, modReference string) (*ficsit.GetModResponse, error) {
+	var errs []error
+	for _, endpoint := range settings.Settings.GetRepositoryEndpoints() {
+		response, err := ficsit.GetMod(ctx, p.client(endpoint), modReference)
+		if err == nil && response.Mod.Id != "" {
+			return response, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("mod %s not found", modReference)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.APIBase, err))
+	}
+	return nil, p.endpointsFailed(errs)
+}
+
+func (p repositoryProvider) GetModName(ctx context.Context, modReference string) (*resolver.ModName, error) {
+	var errs []error
+	for _, endpoint := range settings.Settings.GetRepositoryEndpoints() {
+		response, err := ficsit.GetModName(ctx, p.client(endpoint), modReference)
+		if err == nil && response.Mod.Id != "" {
+			return &resolver.ModName{
+				ID:           response.Mod.Id,
+				ModReference: response.Mod.Mod_reference,
+				Name:         response.Mod.Name,
+			}, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("mod %s not found", modReference)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.APIBase, err))
+	}
+	return nil, p.endpointsFailed(errs)
+}
+
+func (p repositoryProvider) ModVersionsWithDependencies(ctx context.Context, modID string) ([]resolver.ModVersion, error) {
+	var errs []error
+	for _, endpoint := range settings.Settings.GetRepositoryEndpoints() {
+		versions, err := p.getAllModVersions(ctx, endpoint, modID)
+		if err == nil && len(versions) > 0 {
+			// Keep the offline registry up to date, same as the ficsit-cli provider
+			localregistry.Add(modID, versions)
+			return convertRepositoryVersions(endpoint, versions), nil
+		}
+		if err == nil {
+			err = fmt.Errorf("mod %s has no versions", modID)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.APIBase, err))
+	}
+	return nil, p.endpointsFailed(errs)
+}
+
+// endpointsFailed joins the errors of every endpoint, and reports the repository as unreachable
+// if none of the endpoints could be contacted at all. Requests that got a server error also fail with a *url.Error,
+// see serverErrorTransport
+func (p repositoryProvider) endpointsFailed(errs []error) error {
+	err := errors.Join(errs...)
+	if p.onUnreachable == nil || len(errs) == 0 {
+		return err
+	}
+	for _, endpointErr := range errs {
+		var urlErr *url.Error
+		if !errors.As(endpointErr, &urlErr) || errors.Is(endpointErr, context.Canceled) {
+			return err
+		}
+	}
+	p.onUnreachable(err)
+	return err
+}
+
+func (p repositoryProvider) IsOffline() bool {
+	return false
+}
+
+func (p repositoryProvider) getAllModVersions(ctx context.Context, endpoint settings.RepositoryEndpoint, modID string) ([]ficsit.ModVersion, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.APIBase+"/v1/mod/"+modID+"/versions/all", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	response, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching all versions: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading response body: %w", err)
+	}
+
+	allVersions := ficsit.AllVersionsResponse{}
+	if err := json.Unmarshal(body, &allVersions); err != nil {
+		return nil, fmt.Errorf("failed parsing json: %w", err)
+	}
+	if allVersions.Error != nil {
+		return nil, errors.New(allVersions.Error.Message)
+	}
+	return allVersions.Data, nil
+}
+
+// convertRepositoryVersions converts the versions like ficsit-cli does,
+// but links the downloads to the endpoint the versions came from
+func convertRepositoryVersions(endpoint settings.RepositoryEndpoint, versions []ficsit.ModVersion) []resolver.ModVersion {
+	modVersions := make([]resolver.ModVersion, len(versions))
+	for i, modVersion := range versions {
+		dependencies := make([]resolver.Dependency, len(modVersion.Dependencies))
+		for j, dependency := range modVersion.Dependencies {
+			dependencies[j] = resolver.Dependency{
+				ModID:     dependency.ModID,
+				Condition: dependency.Condition,
+				Optional:  dependency.Optional,
+			}
+		}
+
+		targets := make([]resolver.Target, len(modVersion.Targets))
+		for j, target := range modVersion.Targets {
+			targets[j] = resolver.Target{
+				TargetName: resolver.TargetName(target.TargetName),
+				Link:       endpoint.APIBase + target.Link,
+				Hash:       target.Hash,
+				Size:       target.Size,
+			}
+		}
+
+		modVersions[i] = resolver.ModVersion{
+			Version:          modVersion.Version,
+			GameVersion:      modVersion.GameVersion,
+			Dependencies:     dependencies,
+			Targets:          targets,
+			RequiredOnRemote: modVersion.RequiredOnRemote,
+		}
+	}
+	return modVersions
+}
+
+var (
+	repositoryHealth     []RepositoryEndpointHealth
+	repositoryHealthLock sync.RWMutex
+	initialHealthCheck   sync.Once
+)
+
+const repositoryHealthTimeout = 10 * time.Second
+
+// CheckRepositoryEndpoints checks that every repository endpoint answers GraphQL queries
+func (f *ficsitCLI) CheckRepositoryEndpoints() []RepositoryEndpointHealth {
+	p := newRepositoryProvider(nil)
+	endpoints := settings.Settings.GetRepositoryEndpoints()
+
+	results := make([]RepositoryEndpointHealth, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.checkEndpoint(endpoint)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if !result.Healthy {
+			slog.Warn("repository endpoint is unhealthy", slog.String("endpoint", result.APIBase), slog.String("error", result.Error))
+		}
+	}
+
+	repositoryHealthLock.Lock()
+	repositoryHealth = results
+	repositoryHealthLock.Unlock()
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "repositoryHealth", results)
+
+	return results
+}
+
+func (f *ficsitCLI) GetRepositoryHealth() []RepositoryEndpointHealth {
+	repositoryHealthLock.RLock()
+	defer repositoryHealthLock.RUnlock()
+	return repositoryHealth
+}
+
+// GetActiveRepositoryEndpoints returns the GraphQL URLs of the endpoints in the order they should be tried,
+// with the endpoints known to be unhealthy last. It does not wait for a health check,
+// if none was done yet one is started in the background instead
+func (f *ficsitCLI) GetActiveRepositoryEndpoints() []string {
+	endpoints := settings.Settings.GetRepositoryEndpoints()
+
+	// Only worth checking when there is an endpoint to fall back to
+	if len(endpoints) > 1 && f.GetRepositoryHealth() == nil {
+		initialHealthCheck.Do(func() {
+			go f.CheckRepositoryEndpoints()
+		})
+	}
+
+	repositoryHealthLock.RLock()
+	defer repositoryHealthLock.RUnlock()
+
+	healthy := make([]string, 0, len(endpoints))
+	unhealthy := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		isHealthy := true
+		for _, health := range repositoryHealth {
+			if health.APIBase == endpoint.APIBase {
+				isHealthy = health.Healthy
+				break
+			}
+		}
+		if isHealthy {
+			healthy = append(healthy, endpoint.APIBase+endpoint.GraphQLAPI)
+		} else {
+			unhealthy = append(unhealthy, endpoint.APIBase+endpoint.GraphQLAPI)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (p repositoryProvider) checkEndpoint(endpoint settings.RepositoryEndpoint) RepositoryEndpointHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), repositoryHealthTimeout)
+	defer cancel()
+
+	result := RepositoryEndpointHealth{
+		APIBase:   endpoint.APIBase,
+		CheckedAt: time.Now(),
+	}
+
+	start := time.Now()
+	var response struct {
+		Typename string `json:"__typename"`
+	}
+	err := p.client(endpoint).MakeRequest(ctx, &graphql.Request{
+		OpName: "HealthCheck",
+		Query:  "query HealthCheck { __typename }",
+	}, &graphql.Response{Data: &response})
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Healthy = true
+	return result
+}
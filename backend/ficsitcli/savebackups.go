@@ -0,0 +1,431 @@
+package ficsitcli
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	"github.com/spf13/viper"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type SaveBackup struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+	// Profile is the profile the install was using when the backup was made
+	Profile string `json:"profile"`
+	// Changes are the mod removals and downgrades that caused the backup, empty for manual backups
+	Changes []string `json:"changes"`
+}
+
+// saveBackupMetadata is stored as the comment of the backup zip
+type saveBackupMetadata struct {
+	Profile string   `json:"profile"`
+	Changes []string `json:"changes"`
+}
+
+const (
+	saveBackupIDFormat = "2006-01-02-15-04-05.000"
+	// legacySaveBackupIDFormat was used before milliseconds were added to tell apart backups made in the same second
+	legacySaveBackupIDFormat = "2006-01-02-15-04-05"
+)
+
+func saveBackupsDir(installPath string) string {
+	return filepath.Join(viper.GetString("smm-local-dir"), "saveBackups", remoteKey(installPath))
+}
+
+// GetSaveBackups lists the save backups of an install, newest first
+func (f *ficsitCLI) GetSaveBackups(installPath string) ([]SaveBackup, error) {
+	entries, err := os.ReadDir(saveBackupsDir(installPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SaveBackup{}, nil
+		}
+		return nil, fmt.Errorf("failed to read save backups: %w", err)
+	}
+
+	backups := make([]SaveBackup, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zip") {
+			continue
+		}
+		backup, err := readSaveBackup(filepath.Join(saveBackupsDir(installPath), entry.Name()))
+		if err != nil {
+			slog.Warn("failed to read save backup", slog.String("file", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		backups = append(backups, *backup)
+	}
+
+	slices.SortFunc(backups, func(a, b SaveBackup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return backups, nil
+}
+
+func readSaveBackup(path string) (*SaveBackup, error) {
+	id := strings.TrimSuffix(filepath.Base(path), ".zip")
+	createdAt, err := time.ParseInLocation(saveBackupIDFormat, id, time.Local)
+	if err != nil {
+		createdAt, err = time.ParseInLocation(legacySaveBackupIDFormat, id, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup name: %w", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer archive.Close()
+
+	var metadata saveBackupMetadata
+	if archive.Comment != "" {
+		err = json.Unmarshal([]byte(archive.Comment), &metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse backup metadata: %w", err)
+		}
+	}
+	if metadata.Changes == nil {
+		metadata.Changes = []string{}
+	}
+
+	return &SaveBackup{
+		ID:        id,
+		CreatedAt: createdAt,
+		Size:      info.Size(),
+		Profile:   metadata.Profile,
+		Changes:   metadata.Changes,
+	}, nil
+}
+
+// BackupSaves makes a backup of an install's saves right away
+func (f *ficsitCLI) BackupSaves(installPath string) (*SaveBackup, error) {
+	l := slog.With(slog.String("task", "backupSaves"), slog.String("install", installPath))
+
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", installPath)
+	}
+
+	backup, err := f.backupSaves(installation, []string{})
+	if err != nil {
+		l.Error("failed to backup saves", slog.Any("error", err))
+		return nil, err
+	}
+	if backup == nil {
+		return nil, fmt.Errorf("installation has no saves")
+	}
+	return backup, nil
+}
+
+// RestoreSaveBackup writes the saves in the backup back to the install, overwriting the saves with the same name.
+// Saves made after the backup are kept
+func (f *ficsitCLI) RestoreSaveBackup(installPath string, id string) error {
+	l := slog.With(slog.String("task", "restoreSaveBackup"), slog.String("install", installPath), slog.String("backup", id))
+
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.Info == nil {
+		return fmt.Errorf("installation %s is not loaded", installPath)
+	}
+
+	err := f.checkGameNotRunning(installPath)
+	if err != nil {
+		return err
+	}
+
+	backupPath := filepath.Join(saveBackupsDir(installPath), filepath.Base(id)+".zip")
+	archive, err := zip.OpenReader(backupPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("backup %s not found", id)
+		}
+		l.Error("failed to open backup", slog.Any("error", err))
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer archive.Close()
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		l.Error("failed to get disk", slog.Any("error", err))
+		return fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	err = unzipToDisk(&archive.Reader, d, saveGamesPath(meta.Info))
+	if err != nil {
+		l.Error("failed to restore saves", slog.Any("error", err))
+		return fmt.Errorf("failed to restore saves: %w", err)
+	}
+
+	l.Info("restored save backup")
+	return nil
+}
+
+func (f *ficsitCLI) DeleteSaveBackup(installPath string, id string) error {
+	err := os.Remove(filepath.Join(saveBackupsDir(installPath), filepath.Base(id)+".zip"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("backup %s not found", id)
+		}
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+	wailsRuntime.EventsEmit(appCommon.AppContext, "saveBackups", installPath)
+	return nil
+}
+
+// backupSavesBeforeApply backs up the install's saves if the apply would remove or downgrade any of its mods
+func (f *ficsitCLI) backupSavesBeforeApply(l *slog.Logger, installTarget installWithTarget, profile *cli.Profile) error {
+	installation := installTarget.install
+	installed, err := getInstalledModVersions(installation)
+	if err != nil {
+		l.Warn("failed to read installed mods, not backing up saves", slog.String("install", installation.Path), slog.Any("error", err))
+		return nil
+	}
+	if len(installed) == 0 {
+		return nil
+	}
+
+	newLockfile, err := f.resolveForApply(installation, profile)
+	if err != nil {
+		// The apply will fail with a more useful error if the profile cannot be resolved
+		l.Warn("failed to resolve profile, not backing up saves", slog.String("install", installation.Path), slog.Any("error", err))
+		return nil
+	}
+
+	changes := getDestructiveModChanges(installed, newLockfile, installTarget.targetName)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	backup, err := f.backupSaves(installation, changes)
+	if err != nil {
+		l.Error("failed to backup saves", slog.String("install", installation.Path), slog.Any("error", err))
+		return fmt.Errorf("failed to backup saves: %w", err)
+	}
+	if backup != nil {
+		l.Info("backed up saves", slog.String("install", installation.Path), slog.String("backup", backup.ID), slog.Any("changes", changes))
+	}
+	return nil
+}
+
+// resolveForApply resolves the profile the same way the install does, and stores the result as the install's lockfile.
+// The install then resolves from that lockfile, so it keeps exactly the resolved versions
+func (f *ficsitCLI) resolveForApply(installation *cli.Installation, profile *cli.Profile) (*resolver.LockFile, error) {
+	if installation.Vanilla {
+		return resolver.NewLockfile(), nil
+	}
+
+	lockfile, err := installation.LockFile(f.ficsitCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lockfile: %w", err)
+	}
+	if lockfile == nil {
+		lockfile = resolver.NewLockfile()
+	}
+	gameVersion, err := installation.GetGameVersion(f.ficsitCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game version: %w", err)
+	}
+	newLockfile, err := profile.Resolve(resolver.NewDependencyResolver(f.ficsitCli.Provider), lockfile.Clone(), gameVersion)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	err = installation.WriteLockFile(f.ficsitCli, newLockfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return newLockfile, nil
+}
+
+// getDestructiveModChanges lists the installed mods that the new lockfile would remove or downgrade on the target
+func getDestructiveModChanges(installed map[string]string, newLockfile *resolver.LockFile, targetName string) []string {
+	changes := make([]string, 0)
+	for modReference, installedVersion := range installed {
+		lockedMod, ok := newLockfile.Mods[modReference]
+		if ok {
+			_, ok = lockedMod.Targets[targetName]
+		}
+		if !ok {
+			changes = append(changes, fmt.Sprintf("remove %s %s", modReference, installedVersion))
+			continue
+		}
+		if compareVersions(lockedMod.Version, installedVersion) < 0 {
+			changes = append(changes, fmt.Sprintf("downgrade %s %s -> %s", modReference, installedVersion, lockedMod.Version))
+		}
+	}
+	slices.Sort(changes)
+	return changes
+}
+
+// getInstalledModVersions reads the version of every mod in the install's Mods directory from its .uplugin
+func getInstalledModVersions(installation *cli.Installation) (map[string]string, error) {
+	plugins, err := getInstalledModPlugins(installation)
+	if err != nil {
+		return nil, err
+	}
+	installed := make(map[string]string, len(plugins))
+	for modReference, uplugin := range plugins {
+		installed[modReference] = uplugin.SemVersion
+	}
+	return installed, nil
+}
+
+// getInstalledModPlugins reads the .uplugin of every mod in the install's Mods directory.
+// Directories without a .uplugin are not mods, and are skipped
+func getInstalledModPlugins(installation *cli.Installation) (map[string]*ficsitcache.UPlugin, error) {
+	d, err := installation.GetDisk()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	modsDirectory := filepath.Join(installation.BasePath(), "FactoryGame", "Mods")
+	exists, err := d.Exists(modsDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mods directory: %w", err)
+	}
+	if !exists {
+		return map[string]*ficsitcache.UPlugin{}, nil
+	}
+
+	entries, err := d.ReadDir(modsDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mods directory: %w", err)
+	}
+
+	installed := make(map[string]*ficsitcache.UPlugin)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		upluginPath := filepath.Join(modsDirectory, entry.Name(), entry.Name()+".uplugin")
+		exists, err := d.Exists(upluginPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", upluginPath, err)
+		}
+		if !exists {
+			continue
+		}
+		data, err := d.Read(upluginPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", upluginPath, err)
+		}
+		uplugin, err := parseUPlugin(entry.Name()+".uplugin", data)
+		if err != nil {
+			return nil, err
+		}
+		installed[entry.Name()] = uplugin
+	}
+	return installed, nil
+}
+
+// backupSaves zips the install's SaveGames directory, then removes the backups above the retention limit.
+// Returns nil if the install has no saves
+func (f *ficsitCLI) backupSaves(installation *cli.Installation, changes []string) (*SaveBackup, error) {
+	meta, ok := f.installationMetadata.Load(installation.Path)
+	if !ok || meta.Info == nil {
+		return nil, fmt.Errorf("installation %s is not loaded", installation.Path)
+	}
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	savesPath := saveGamesPath(meta.Info)
+	exists, err := d.Exists(savesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check saves directory: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	backupsDir := saveBackupsDir(installation.Path)
+	err = os.MkdirAll(backupsDir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backups directory: %w", err)
+	}
+
+	// Never overwrite an existing backup, if one was made in the same millisecond the next free ID is used
+	createdAt := time.Now()
+	var file *os.File
+	var backupPath string
+	for {
+		backupPath = filepath.Join(backupsDir, createdAt.Format(saveBackupIDFormat)+".zip")
+		file, err = os.OpenFile(backupPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !errors.Is(err, os.ErrExist) {
+			break
+		}
+		createdAt = createdAt.Add(time.Millisecond)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	metadata, err := json.Marshal(saveBackupMetadata{
+		Profile: installation.Profile,
+		Changes: changes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup metadata: %w", err)
+	}
+
+	err = zipDiskDirectory(file, d, savesPath, string(metadata))
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(backupPath)
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	f.pruneSaveBackups(installation.Path)
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "saveBackups", installation.Path)
+
+	return readSaveBackup(backupPath)
+}
+
+// pruneSaveBackups removes the oldest backups of an install above the retention limit
+func (f *ficsitCLI) pruneSaveBackups(installPath string) {
+	backups, err := f.GetSaveBackups(installPath)
+	if err != nil {
+		slog.Warn("failed to list save backups", slog.Any("error", err))
+		return
+	}
+	retention := settings.Settings.GetSaveBackupRetention()
+	if len(backups) <= retention {
+		return
+	}
+	for _, backup := range backups[retention:] {
+		err := os.Remove(filepath.Join(saveBackupsDir(installPath), backup.ID+".zip"))
+		if err != nil {
+			slog.Warn("failed to remove old save backup", slog.String("backup", backup.ID), slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package ficsitcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/savegame"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type SaveGame struct {
+	// Path is relative to the install's SaveGames directory
+	Path        string `json:"path"`
+	SaveName    string `json:"saveName"`
+	SessionName string `json:"sessionName"`
+	// PlayDuration is in seconds
+	PlayDuration int       `json:"playDuration"`
+	LastModified time.Time `json:"lastModified"`
+	BuildVersion int       `json:"buildVersion"`
+	IsModded     bool      `json:"isModded"`
+	// Profile is the profile the save was last played with, if it was launched from SMM
+	Profile *string `json:"profile"`
+	Error   *string `json:"error"`
+}
+
+// Saves are linked to profiles by recording which profile each install was launched with,
+// then a save belongs to the profile of the last launch before it was written
+type saveProfiles struct {
+	Installs map[string]*installSaveProfiles `json:"installs"`
+}
+
+type installSaveProfiles struct {
+	Launches []profileLaunch        `json:"launches"`
+	Saves    map[string]saveProfile `json:"saves"`
+}
+
+type profileLaunch struct {
+	Profile string    `json:"profile"`
+	Time    time.Time `json:"time"`
+}
+
+type saveProfile struct {
+	Profile  string    `json:"profile"`
+	SaveTime time.Time `json:"saveTime"`
+}
+
+var saveProfilesLock sync.Mutex
+
+const (
+	saveProfilesFileName = "saveProfiles.json"
+	maxRecordedLaunches  = 100
+)
+
+func saveGamesPath(meta *common.Installation) string {
+	return filepath.Join(meta.SavedPath, "SaveGames")
+}
+
+// GetSaveGames lists the saves of an install, newest first
+func (f *ficsitCLI) GetSaveGames(installPath string) ([]SaveGame, error) {
+	l := slog.With(slog.String("task", "getSaveGames"), slog.String("install", installPath))
+
+	installation := f.GetInstallation(installPath)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.Info == nil {
+		return nil, fmt.Errorf("installation %s is not loaded", installPath)
+	}
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		l.Error("failed to get disk", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	savesPath := saveGamesPath(meta.Info)
+	files, err := listDiskFiles(d, savesPath, "")
+	if err != nil {
+		l.Error("failed to list saves", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to list saves: %w", err)
+	}
+
+	// Remote saves can only be read whole, so their headers are cached until the file changes
+	var remoteInfos map[string]diskFileInfo
+	if meta.Info.Location != common.LocationTypeLocal && len(files) > 0 {
+		remoteInfos, err = f.listFileInfos(installation, d, savesPath)
+		if err != nil {
+			l.Warn("failed to list save file info, saves will not be cached", slog.Any("error", err))
+		}
+	}
+
+	saves := make([]SaveGame, 0, len(files))
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".sav") {
+			continue
+		}
+		save := SaveGame{
+			Path: file,
+		}
+
+		var header *savegame.Header
+		var modTime *time.Time
+		if info, ok := remoteInfos[file]; ok {
+			header, err = readCachedSaveHeader(d, installPath, filepath.Join(savesPath, file), info)
+			modTime = &info.ModTime
+		} else {
+			header, modTime, err = readSaveHeader(d, meta.Info, filepath.Join(savesPath, file))
+		}
+		if err != nil {
+			l.Warn("failed to read save", slog.String("save", file), slog.Any("error", err))
+			errString := err.Error()
+			save.Error = &errString
+			saves = append(saves, save)
+			continue
+		}
+		save.SaveName = header.SaveName
+		save.SessionName = header.SessionName
+		save.PlayDuration = int(header.PlayDuration)
+		save.BuildVersion = int(header.BuildVersion)
+		save.IsModded = header.IsModdedSave
+		save.LastModified = header.SaveDateTime
+		if modTime != nil {
+			save.LastModified = *modTime
+		}
+		saves = append(saves, save)
+	}
+
+	err = f.linkSaveProfiles(installPath, saves)
+	if err != nil {
+		l.Warn("failed to link saves to profiles", slog.Any("error", err))
+	}
+
+	slices.SortStableFunc(saves, func(a, b SaveGame) int {
+		return b.LastModified.Compare(a.LastModified)
+	})
+
+	return saves, nil
+}
+
+// readSaveHeader returns the header of a save, and its modification time when it is available
+func readSaveHeader(d disk.Disk, meta *common.Installation, path string) (*savegame.Header, *time.Time, error) {
+	if meta.Location == common.LocationTypeLocal {
+		// Avoid reading the whole save, only the header is needed
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to stat save: %w", err)
+		}
+		header, err := savegame.ReadHeaderFile(path)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+		modTime := info.ModTime()
+		return header, &modTime, nil
+	}
+
+	// The disk abstraction can only read whole files
+	data, err := d.Read(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read save: %w", err)
+	}
+	header, err := savegame.ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+	return header, nil, nil
+}
+
+type cachedSaveHeader struct {
+	info   diskFileInfo
+	header *savegame.Header
+}
+
+var (
+	saveHeaderCache     = make(map[string]cachedSaveHeader)
+	saveHeaderCacheLock sync.Mutex
+)
+
+// readCachedSaveHeader reads the header of a remote save, unless it was already read and the file did not change since
+func readCachedSaveHeader(d disk.Disk, installPath string, path string, info diskFileInfo) (*savegame.Header, error) {
+	key := remoteKey(installPath) + "/" + path
+
+	saveHeaderCacheLock.Lock()
+	cached, ok := saveHeaderCache[key]
+	saveHeaderCacheLock.Unlock()
+	if ok && cached.info.Size == info.Size && cached.info.ModTime.Equal(info.ModTime) {
+		return cached.header, nil
+	}
+
+	header, _, err := readSaveHeader(d, &common.Installation{Location: common.LocationTypeRemote}, path)
+	if err != nil {
+		return nil, err
+	}
+
+	saveHeaderCacheLock.Lock()
+	saveHeaderCache[key] = cachedSaveHeader{info: info, header: header}
+	saveHeaderCacheLock.Unlock()
+	return header, nil
+}
+
+// SetSaveGameProfile links a save to a profile, overriding the profile it was last played with
+func (f *ficsitCLI) SetSaveGameProfile(installPath string, savePath string, profileName string) error {
+	if f.GetProfile(profileName) == nil {
+		return fmt.Errorf("profile %s not found", profileName)
+	}
+
+	saveProfilesLock.Lock()
+	defer saveProfilesLock.Unlock()
+
+	data := loadSaveProfiles()
+	install := data.install(installPath)
+	install.Saves[savePath] = saveProfile{
+		Profile:  profileName,
+		SaveTime: time.Now(),
+	}
+	return data.save()
+}
+
+// LaunchSaveGame switches the selected install to the profile the save was last played with, then launches the game
+func (f *ficsitCLI) LaunchSaveGame(savePath string) error {
+	l := slog.With(slog.String("task", "launchSaveGame"), slog.String("save", savePath))
+
+	selectedInstallation := f.GetSelectedInstall()
+	if selectedInstallation == nil {
+		l.Error("no installation selected")
+		return fmt.Errorf("no installation selected")
+	}
+
+	saves, err := f.GetSaveGames(selectedInstallation.Path)
+	if err != nil {
+		return err
+	}
+	saveIdx := slices.IndexFunc(saves, func(save SaveGame) bool { return save.Path == savePath })
+	if saveIdx == -1 {
+		return fmt.Errorf("save %s not found", savePath)
+	}
+
+	profile := saves[saveIdx].Profile
+	if profile != nil && *profile != selectedInstallation.Profile {
+		l.Info("switching to the save's profile", slog.String("profile", *profile))
+		err = f.SetProfile(*profile)
+		if err != nil {
+			return err
+		}
+		// SetProfile only applies when the queue auto starts, but the game must start with the save's mods
+		if !settings.Settings.QueueAutoStart {
+			err = f.Apply()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	f.LaunchGame()
+	return nil
+}
+
+// recordLaunch remembers the profile the install is launched with, to link the saves written afterwards to it
+func (f *ficsitCLI) recordLaunch(installation *cli.Installation) {
+	saveProfilesLock.Lock()
+	defer saveProfilesLock.Unlock()
+
+	data := loadSaveProfiles()
+	install := data.install(installation.Path)
+	install.Launches = append(install.Launches, profileLaunch{
+		Profile: installation.Profile,
+		Time:    time.Now(),
+	})
+	if len(install.Launches) > maxRecordedLaunches {
+		install.Launches = install.Launches[len(install.Launches)-maxRecordedLaunches:]
+	}
+	err := data.save()
+	if err != nil {
+		slog.Warn("failed to record launch", slog.Any("error", err))
+	}
+}
+
+// linkSaveProfiles sets the profile of each save, linking saves written after a recorded launch to that launch's profile
+func (f *ficsitCLI) linkSaveProfiles(installPath string, saves []SaveGame) error {
+	saveProfilesLock.Lock()
+	defer saveProfilesLock.Unlock()
+
+	data := loadSaveProfiles()
+	install := data.install(installPath)
+
+	changed := false
+	for i := range saves {
+		save := &saves[i]
+		if save.Error != nil {
+			continue
+		}
+
+		existing, ok := install.Saves[save.Path]
+		if !ok || existing.SaveTime.Before(save.LastModified) {
+			launchIdx := -1
+			for idx := len(install.Launches) - 1; idx >= 0; idx-- {
+				if !install.Launches[idx].Time.After(save.LastModified) {
+					launchIdx = idx
+					break
+				}
+			}
+			if launchIdx != -1 {
+				launch := install.Launches[launchIdx]
+				existing = saveProfile{
+					Profile:  launch.Profile,
+					SaveTime: save.LastModified,
+				}
+				install.Saves[save.Path] = existing
+				ok = true
+				changed = true
+			}
+		}
+
+		if ok && f.GetProfile(existing.Profile) != nil {
+			profile := existing.Profile
+			save.Profile = &profile
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return data.save()
+}
+
+func (f *ficsitCLI) renameSaveProfiles(oldName string, newName string) {
+	saveProfilesLock.Lock()
+	defer saveProfilesLock.Unlock()
+
+	data := loadSaveProfiles()
+	for _, install := range data.Installs {
+		for i := range install.Launches {
+			if install.Launches[i].Profile == oldName {
+				install.Launches[i].Profile = newName
+			}
+		}
+		for savePath, save := range install.Saves {
+			if save.Profile == oldName {
+				save.Profile = newName
+				install.Saves[savePath] = save
+			}
+		}
+	}
+	err := data.save()
+	if err != nil {
+		slog.Error("failed to rename save profiles", slog.Any("error", err))
+	}
+}
+
+func (s *saveProfiles) install(installPath string) *installSaveProfiles {
+	key := remoteKey(installPath)
+	install, ok := s.Installs[key]
+	if !ok {
+		install = &installSaveProfiles{}
+		s.Installs[key] = install
+	}
+	if install.Saves == nil {
+		install.Saves = make(map[string]saveProfile)
+	}
+	return install
+}
+
+func loadSaveProfiles() *saveProfiles {
+	data := &saveProfiles{
+		Installs: make(map[string]*installSaveProfiles),
+	}
+	fileBytes, err := os.ReadFile(filepath.Join(viper.GetString("smm-local-dir"), saveProfilesFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read save profiles", slog.Any("error", err))
+		}
+		return data
+	}
+	err = json.Unmarshal(fileBytes, data)
+	if err != nil || data.Installs == nil {
+		slog.Warn("failed to parse save profiles", slog.Any("error", err))
+		data.Installs = make(map[string]*installSaveProfiles)
+	}
+	return data
+}
+
+func (s *saveProfiles) save() error {
+	fileBytes, err := utils.JSONMarshal(s, 2)
+	if err != nil {
+		return fmt.Errorf("failed to marshal save profiles: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(viper.GetString("smm-local-dir"), saveProfilesFileName), fileBytes, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to write save profiles: %w", err)
+	}
+	return nil
+}
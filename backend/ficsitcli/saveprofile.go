@@ -0,0 +1,204 @@
+package ficsitcli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/savegame"
+)
+
+type SaveModCheck struct {
+	ModReference string        `json:"modReference"`
+	Name         string        `json:"name"`
+	Version      string        `json:"version"`
+	Status       SaveModStatus `json:"status"`
+	Error        *string       `json:"error"`
+}
+
+type SaveModsReport struct {
+	SessionName string `json:"sessionName"`
+	// BuildVersion is the game version the save was made with
+	BuildVersion int `json:"buildVersion"`
+	// GameVersion is the game version of the selected install, that the mods were checked against
+	GameVersion int            `json:"gameVersion"`
+	Mods        []SaveModCheck `json:"mods"`
+	// Resolved is true when all the mods can be installed together at the save's versions
+	Resolved bool `json:"resolved"`
+}
+
+// ReadSaveMods reads the mods a save was made with, and checks if they can be installed on the selected install
+func (f *ficsitCLI) ReadSaveMods(file string) (*SaveModsReport, error) {
+	l := slog.With(slog.String("task", "readSaveMods"), slog.String("file", file))
+
+	selectedInstallation := f.GetSelectedInstall()
+	if selectedInstallation == nil {
+		l.Error("no installation selected")
+		return nil, fmt.Errorf("no installation selected")
+	}
+
+	report, err := f.checkSaveMods(selectedInstallation, file)
+	if err != nil {
+		l.Error("failed to check save mods", slog.Any("error", err))
+		return nil, err
+	}
+	return report, nil
+}
+
+// CreateProfileFromSave creates a profile with the save's mods at their exact versions, and applies it to the selected install.
+// Mods that are unavailable or incompatible are left out of the profile, and listed in the returned report
+func (f *ficsitCLI) CreateProfileFromSave(name string, file string) (*SaveModsReport, error) {
+	var report *SaveModsReport
+	err := f.action(ActionImportSave, newSimpleItem(name), func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		l = l.With(slog.String("file", file))
+
+		selectedInstallation := f.GetSelectedInstall()
+		if selectedInstallation == nil {
+			l.Error("no installation selected")
+			return fmt.Errorf("no installation selected")
+		}
+
+		// Switching to the new profile swaps the install's configs
+		err := f.checkGameNotRunning(selectedInstallation.Path)
+		if err != nil {
+			return err
+		}
+
+		report, err = f.checkSaveMods(selectedInstallation, file)
+		if err != nil {
+			l.Error("failed to check save mods", slog.Any("error", err))
+			return err
+		}
+
+		profile, err := f.ficsitCli.Profiles.AddProfile(name)
+		if err != nil {
+			l.Error("failed to add profile", slog.Any("error", err))
+			return fmt.Errorf("failed to add profile: %w", err)
+		}
+
+		for _, mod := range report.Mods {
+			if mod.Status != SaveModStatusAvailable {
+				continue
+			}
+			err = profile.AddMod(mod.ModReference, mod.Version)
+			if err != nil {
+				_ = f.ficsitCli.Profiles.DeleteProfile(name)
+				l.Error("failed to add mod", slog.String("mod", mod.ModReference), slog.Any("error", err))
+				return fmt.Errorf("failed to add mod: %s@%s: %w", mod.ModReference, mod.Version, err)
+			}
+		}
+
+		currentProfile := selectedInstallation.Profile
+		err = selectedInstallation.SetProfile(f.ficsitCli, name)
+		if err != nil {
+			_ = f.ficsitCli.Profiles.DeleteProfile(name)
+			l.Error("failed to set profile", slog.Any("error", err))
+			return fmt.Errorf("failed to set profile: %w", err)
+		}
+		f.switchProfileConfigs(l, selectedInstallation, currentProfile, name)
+
+		f.EmitGlobals()
+
+		installErr := f.apply(l, taskChannel)
+		if installErr != nil {
+			_ = selectedInstallation.SetProfile(f.ficsitCli, currentProfile)
+			f.switchProfileConfigs(l, selectedInstallation, name, currentProfile)
+			_ = f.ficsitCli.Profiles.DeleteProfile(name)
+			f.deleteProfileConfigs(name)
+			f.EmitGlobals()
+			l.Error("failed to apply profile", slog.Any("error", installErr))
+			return installErr
+		}
+
+		err = f.ficsitCli.Profiles.Save()
+		if err != nil {
+			l.Error("failed to save profile", slog.Any("error", err))
+		}
+		err = f.ficsitCli.Installations.Save()
+		if err != nil {
+			l.Error("failed to save installations", slog.Any("error", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (f *ficsitCLI) checkSaveMods(installation *cli.Installation, file string) (*SaveModsReport, error) {
+	header, err := savegame.ReadHeaderFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read save: %w", err)
+	}
+
+	meta, ok := f.installationMetadata.Load(installation.Path)
+	if !ok || meta.Info == nil {
+		return nil, fmt.Errorf("installation %s is not loaded", installation.Path)
+	}
+	platform, err := installation.GetPlatform(f.ficsitCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get platform: %w", err)
+	}
+	targets := []resolver.TargetName{resolver.TargetName(platform.TargetName)}
+
+	report := &SaveModsReport{
+		SessionName:  header.SessionName,
+		BuildVersion: int(header.BuildVersion),
+		GameVersion:  meta.Info.Version,
+		Mods:         []SaveModCheck{},
+	}
+	if header.ModMetadata == nil {
+		report.Resolved = true
+		return report, nil
+	}
+
+	depResolver := resolver.NewDependencyResolver(f.ficsitCli.Provider)
+
+	constraints := make(map[string]string)
+	for _, mod := range header.ModMetadata.Mods {
+		check := SaveModCheck{
+			ModReference: mod.Reference,
+			Name:         mod.Name,
+			Version:      mod.Version,
+			Status:       SaveModStatusAvailable,
+		}
+
+		versions, err := f.ficsitCli.Provider.ModVersionsWithDependencies(context.TODO(), mod.Reference)
+		switch {
+		case err != nil:
+			check.Status = SaveModStatusUnavailable
+			errString := err.Error()
+			check.Error = &errString
+		case !slices.ContainsFunc(versions, func(version resolver.ModVersion) bool { return version.Version == mod.Version }):
+			check.Status = SaveModStatusVersionUnavailable
+		default:
+			// Checking each mod on its own tells which ones cannot be installed at all on this game version
+			_, err := depResolver.ResolveModDependencies(map[string]string{mod.Reference: mod.Version}, nil, meta.Info.Version, targets)
+			if err != nil {
+				check.Status = SaveModStatusIncompatible
+				errString := err.Error()
+				check.Error = &errString
+			} else {
+				constraints[mod.Reference] = mod.Version
+			}
+		}
+
+		report.Mods = append(report.Mods, check)
+	}
+
+	slices.SortFunc(report.Mods, func(a, b SaveModCheck) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	_, err = depResolver.ResolveModDependencies(constraints, nil, meta.Info.Version, targets)
+	report.Resolved = err == nil && len(constraints) == len(report.Mods)
+
+	return report, nil
+}
@@ -0,0 +1,415 @@
+package ficsitcli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type ServerState struct {
+	Install   string       `json:"install"`
+	Status    ServerStatus `json:"status"`
+	PID       int          `json:"pid"`
+	Args      []string     `json:"args"`
+	StartedAt *time.Time   `json:"startedAt"`
+	// ExitCode is set once the server exited
+	ExitCode *int `json:"exitCode"`
+}
+
+type ServerOutputLine struct {
+	// Stream is stdout or stderr
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+	Text   string    `json:"text"`
+}
+
+type ServerOutput struct {
+	Install string             `json:"install"`
+	Lines   []ServerOutputLine `json:"lines"`
+}
+
+type serverProcesses struct {
+	servers map[string]*serverProcess
+	lock    sync.Mutex
+}
+
+type serverProcess struct {
+	cmd *exec.Cmd
+	// tree holds every process of the server, the start script or launcher does not run the server binary itself
+	tree  *serverTree
+	state ServerState
+	// output keeps the last lines, pending are the lines not sent to the frontend yet
+	output        []ServerOutputLine
+	pending       []ServerOutputLine
+	stopRequested bool
+	done          chan struct{}
+}
+
+const (
+	maxServerOutputLines   = 2000
+	serverOutputInterval   = 250 * time.Millisecond
+	serverGracefulShutdown = 30 * time.Second
+	serverTreePollInterval = 100 * time.Millisecond
+)
+
+func (f *ficsitCLI) GetServerOptions(installPath string) settings.ServerOptions {
+	return settings.Settings.LocalServerOptions[remoteKey(installPath)]
+}
+
+func (f *ficsitCLI) SetServerOptions(installPath string, options settings.ServerOptions) error {
+	_, err := f.getServerExecutable(installPath)
+	if err != nil {
+		return err
+	}
+	if options.Port < 0 || options.Port > 65535 {
+		return fmt.Errorf("invalid port %d", options.Port)
+	}
+	for _, arg := range options.ExtraArgs {
+		if strings.TrimSpace(arg) == "" || strings.ContainsAny(arg, "\x00\r\n") {
+			return fmt.Errorf("invalid server argument %q", arg)
+		}
+	}
+
+	if settings.Settings.LocalServerOptions == nil {
+		settings.Settings.LocalServerOptions = map[string]settings.ServerOptions{}
+	}
+	settings.Settings.LocalServerOptions[remoteKey(installPath)] = options
+	_ = settings.SaveSettings()
+	return nil
+}
+
+// GetServerState returns the state of the server SMM runs for the install, stopped if it is not running
+func (f *ficsitCLI) GetServerState(installPath string) ServerState {
+	f.servers.lock.Lock()
+	defer f.servers.lock.Unlock()
+	server, ok := f.servers.servers[installPath]
+	if !ok {
+		return ServerState{Install: installPath, Status: ServerStatusStopped, Args: []string{}}
+	}
+	return server.state
+}
+
+// GetServerOutput returns the last lines the server wrote to stdout and stderr
+func (f *ficsitCLI) GetServerOutput(installPath string) []ServerOutputLine {
+	f.servers.lock.Lock()
+	defer f.servers.lock.Unlock()
+	server, ok := f.servers.servers[installPath]
+	if !ok {
+		return []ServerOutputLine{}
+	}
+	return append([]ServerOutputLine{}, server.output...)
+}
+
+// StartServer runs the dedicated server of a local server install
+func (f *ficsitCLI) StartServer(installPath string) error {
+	executable, err := f.getServerExecutable(installPath)
+	if err != nil {
+		return err
+	}
+
+	f.servers.lock.Lock()
+	defer f.servers.lock.Unlock()
+
+	if server, ok := f.servers.servers[installPath]; ok && (server.state.Status == ServerStatusRunning || server.state.Status == ServerStatusStopping) {
+		return fmt.Errorf("server %s is already running", installPath)
+	}
+
+	args := serverArgs(f.GetServerOptions(installPath))
+	l := slog.With(slog.String("install", installPath))
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = installPath
+	configureServerCommand(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get server stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get server stderr: %w", err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		l.Error("failed to start server", slog.Any("error", err))
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	tree, err := newServerTree(cmd)
+	if err != nil {
+		l.Error("failed to track server processes", slog.Any("error", err))
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	now := time.Now()
+	server := &serverProcess{
+		cmd:  cmd,
+		tree: tree,
+		state: ServerState{
+			Install:   installPath,
+			Status:    ServerStatusRunning,
+			PID:       cmd.Process.Pid,
+			Args:      args,
+			StartedAt: &now,
+		},
+		output: []ServerOutputLine{},
+		done:   make(chan struct{}),
+	}
+	f.servers.servers[installPath] = server
+	l.Info("server started", slog.Int("pid", server.state.PID), slog.String("args", strings.Join(args, " ")))
+	wailsRuntime.EventsEmit(appCommon.AppContext, "serverStatus", server.state)
+
+	var outputWg sync.WaitGroup
+	outputWg.Add(2)
+	go f.readServerOutput(server, "stdout", stdout, &outputWg)
+	go f.readServerOutput(server, "stderr", stderr, &outputWg)
+
+	stopFlush := make(chan struct{})
+	go f.flushServerOutput(installPath, server, stopFlush)
+
+	go func() {
+		// Wait must only be called after the pipes are read to the end
+		outputWg.Wait()
+		waitErr := cmd.Wait()
+		// The launcher can exit before the server it started, whose files stay in use until it exited too
+		tree.wait()
+		tree.close()
+		close(stopFlush)
+
+		exitCode := cmd.ProcessState.ExitCode()
+
+		f.servers.lock.Lock()
+		server.state.ExitCode = &exitCode
+		if server.stopRequested || (waitErr == nil && exitCode == 0) {
+			server.state.Status = ServerStatusStopped
+		} else {
+			server.state.Status = ServerStatusCrashed
+		}
+		state := server.state
+		f.servers.lock.Unlock()
+
+		l.Info("server exited", slog.Int("exitCode", exitCode), slog.String("status", string(state.Status)))
+		f.emitServerOutput(installPath, server)
+		wailsRuntime.EventsEmit(appCommon.AppContext, "serverStatus", state)
+		close(server.done)
+	}()
+
+	return nil
+}
+
+// StopServer asks the server to shut down, and kills it if it has not exited after serverGracefulShutdown.
+// It returns once every process of the server exited
+func (f *ficsitCLI) StopServer(installPath string) error {
+	f.servers.lock.Lock()
+	server, ok := f.servers.servers[installPath]
+	if !ok || server.state.Status != ServerStatusRunning {
+		stopping := ok && server.state.Status == ServerStatusStopping
+		f.servers.lock.Unlock()
+		if stopping {
+			<-server.done
+		}
+		return nil
+	}
+	server.stopRequested = true
+	server.state.Status = ServerStatusStopping
+	state := server.state
+	f.servers.lock.Unlock()
+
+	l := slog.With(slog.String("install", installPath))
+	l.Info("stopping server")
+	wailsRuntime.EventsEmit(appCommon.AppContext, "serverStatus", state)
+
+	err := server.tree.interrupt()
+	if err != nil {
+		l.Warn("failed to interrupt server, killing it", slog.Any("error", err))
+	} else {
+		select {
+		case <-server.done:
+			return nil
+		case <-time.After(serverGracefulShutdown):
+			l.Warn("server did not stop in time, killing it")
+		}
+	}
+
+	err = server.tree.kill()
+	if err != nil {
+		l.Error("failed to kill server", slog.Any("error", err))
+		return err
+	}
+	<-server.done
+	return nil
+}
+
+func (f *ficsitCLI) RestartServer(installPath string) error {
+	err := f.StopServer(installPath)
+	if err != nil {
+		return err
+	}
+	return f.StartServer(installPath)
+}
+
+// StopAllServers stops the servers SMM started, so that they do not keep running without their output being read
+func (f *ficsitCLI) StopAllServers() {
+	f.servers.lock.Lock()
+	installPaths := make([]string, 0, len(f.servers.servers))
+	for installPath := range f.servers.servers {
+		installPaths = append(installPaths, installPath)
+	}
+	f.servers.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, installPath := range installPaths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := f.StopServer(installPath)
+			if err != nil {
+				slog.Error("failed to stop server", slog.String("install", installPath), slog.Any("error", err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// stopServersForApply stops the running servers of the installs, since their mods cannot be replaced while loaded.
+// Returns the servers to start again after the apply
+func (f *ficsitCLI) stopServersForApply(l *slog.Logger, installPaths ...string) ([]string, error) {
+	var restart []string
+	for _, installPath := range installPaths {
+		if f.GetServerState(installPath).Status != ServerStatusRunning {
+			continue
+		}
+		l.Info("stopping server before apply", slog.String("install", installPath))
+		err := f.StopServer(installPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stop server %s: %w", installPath, err)
+		}
+		if f.GetServerOptions(installPath).RestartAfterApply {
+			restart = append(restart, installPath)
+		}
+	}
+	return restart, nil
+}
+
+func (f *ficsitCLI) restartServersAfterApply(l *slog.Logger, installPaths []string) {
+	for _, installPath := range installPaths {
+		l.Info("starting server after apply", slog.String("install", installPath))
+		err := f.StartServer(installPath)
+		if err != nil {
+			l.Error("failed to start server after apply", slog.String("install", installPath), slog.Any("error", err))
+		}
+	}
+}
+
+// getServerExecutable returns the start script of a server install that SMM can run on this system
+func (f *ficsitCLI) getServerExecutable(installPath string) (string, error) {
+	if f.GetInstallation(installPath) == nil {
+		return "", fmt.Errorf("installation %s not found", installPath)
+	}
+	meta, ok := f.installationMetadata.Load(installPath)
+	if !ok || meta.Info == nil {
+		return "", fmt.Errorf("installation %s is not loaded", installPath)
+	}
+	// Servers added by path are remote installs, but can still be run if the path is on this machine
+	if meta.Info.Location != common.LocationTypeLocal && !filepath.IsAbs(installPath) {
+		return "", fmt.Errorf("installation %s is not on this computer", installPath)
+	}
+
+	switch meta.Info.Type {
+	case common.InstallTypeLinuxServer:
+		if runtime.GOOS != "linux" {
+			return "", fmt.Errorf("linux servers can only be run on Linux")
+		}
+		return filepath.Join(installPath, "FactoryServer.sh"), nil
+	case common.InstallTypeWindowsServer:
+		if runtime.GOOS != "windows" {
+			return "", fmt.Errorf("windows servers can only be run on Windows")
+		}
+		return filepath.Join(installPath, "FactoryServer.exe"), nil
+	default:
+		return "", fmt.Errorf("installation %s is not a dedicated server", installPath)
+	}
+}
+
+func serverArgs(options settings.ServerOptions) []string {
+	args := []string{}
+	if options.Port != 0 {
+		args = append(args, "-Port="+strconv.Itoa(options.Port))
+	}
+	if options.Multihome != "" {
+		args = append(args, "-multihome="+options.Multihome)
+	}
+	if options.Log {
+		args = append(args, "-log")
+	}
+	return append(args, options.ExtraArgs...)
+}
+
+func (f *ficsitCLI) readServerOutput(server *serverProcess, stream string, reader io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := ServerOutputLine{
+			Stream: stream,
+			Time:   time.Now(),
+			Text:   strings.TrimRight(scanner.Text(), "\r"),
+		}
+		f.servers.lock.Lock()
+		server.output = append(server.output, line)
+		if len(server.output) > maxServerOutputLines {
+			server.output = append([]ServerOutputLine{}, server.output[len(server.output)-maxServerOutputLines:]...)
+		}
+		server.pending = append(server.pending, line)
+		f.servers.lock.Unlock()
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		slog.Warn("failed to read server output", slog.String("stream", stream), slog.Any("error", err))
+		// The rest must still be drained, or the server blocks on writing
+		_, _ = io.Copy(io.Discard, reader)
+	}
+}
+
+// flushServerOutput sends the output in batches, since servers can log thousands of lines while starting
+func (f *ficsitCLI) flushServerOutput(installPath string, server *serverProcess, stop <-chan struct{}) {
+	ticker := time.NewTicker(serverOutputInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			f.emitServerOutput(installPath, server)
+		}
+	}
+}
+
+func (f *ficsitCLI) emitServerOutput(installPath string, server *serverProcess) {
+	f.servers.lock.Lock()
+	lines := server.pending
+	server.pending = nil
+	f.servers.lock.Unlock()
+	if len(lines) == 0 {
+		return
+	}
+	wailsRuntime.EventsEmit(appCommon.AppContext, "serverOutput", ServerOutput{
+		Install: installPath,
+		Lines:   lines,
+	})
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package ficsitcli
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// FactoryServer.sh does not exec the server binary, so the whole process group is signalled
+
+type serverTree struct {
+	pgid int
+}
+
+func configureServerCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func newServerTree(cmd *exec.Cmd) (*serverTree, error) {
+	return &serverTree{pgid: cmd.Process.Pid}, nil
+}
+
+func (t *serverTree) interrupt() error {
+	err := syscall.Kill(-t.pgid, syscall.SIGINT)
+	if err != nil {
+		return fmt.Errorf("failed to interrupt server: %w", err)
+	}
+	return nil
+}
+
+func (t *serverTree) kill() error {
+	err := syscall.Kill(-t.pgid, syscall.SIGKILL)
+	if err != nil {
+		return fmt.Errorf("failed to kill server: %w", err)
+	}
+	return nil
+}
+
+// wait blocks until no process of the group is left
+func (t *serverTree) wait() {
+	for syscall.Kill(-t.pgid, 0) == nil {
+		time.Sleep(serverTreePollInterval)
+	}
+}
+
+func (t *serverTree) close() {}
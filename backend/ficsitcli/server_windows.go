@@ -0,0 +1,173 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// FactoryServer.exe only starts the actual server binary and waits for it,
+// so the server runs in a job object, which holds the whole process tree
+
+var (
+	kernel32          = windows.NewLazySystemDLL("kernel32.dll")
+	procAttachConsole = kernel32.NewProc("AttachConsole")
+	procFreeConsole   = kernel32.NewProc("FreeConsole")
+	// serverConsoleLock is held while attached to a server's console, since SMM can only be attached to one at a time
+	serverConsoleLock sync.Mutex
+)
+
+type serverTree struct {
+	pid int
+	job windows.Handle
+	// closed is set once the job handle is closed, after which the server already exited
+	closed bool
+	lock   sync.Mutex
+}
+
+// jobObjectBasicAccountingInformation is JOBOBJECT_BASIC_ACCOUNTING_INFORMATION, which x/sys/windows does not define
+type jobObjectBasicAccountingInformation struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+func configureServerCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow: true,
+		// Suspended until it is in the job, so that it cannot start the server outside of it
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.CREATE_SUSPENDED,
+	}
+}
+
+// newServerTree puts the started server in a job object that kills its processes when closed, and resumes it
+func newServerTree(cmd *exec.Cmd) (*serverTree, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job object: %w", err)
+	}
+	tree := &serverTree{pid: cmd.Process.Pid, job: job}
+
+	err = tree.assign()
+	if err != nil {
+		tree.close()
+		return nil, err
+	}
+	return tree, nil
+}
+
+func (t *serverTree) assign() error {
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	_, err := windows.SetInformationJobObject(t.job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		return fmt.Errorf("failed to configure job object: %w", err)
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(t.pid))
+	if err != nil {
+		return fmt.Errorf("failed to open server process: %w", err)
+	}
+	defer windows.CloseHandle(process) //nolint:errcheck
+	err = windows.AssignProcessToJobObject(t.job, process)
+	if err != nil {
+		return fmt.Errorf("failed to assign server to job object: %w", err)
+	}
+
+	return resumeProcess(t.pid)
+}
+
+// resumeProcess resumes the threads of a process started with CREATE_SUSPENDED,
+// since os/exec does not keep the handle of its main thread
+func resumeProcess(pid int) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return fmt.Errorf("failed to list threads: %w", err)
+	}
+	defer windows.CloseHandle(snapshot) //nolint:errcheck
+
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != uint32(pid) {
+			continue
+		}
+		thread, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			return fmt.Errorf("failed to open server thread: %w", err)
+		}
+		_, err = windows.ResumeThread(thread)
+		_ = windows.CloseHandle(thread)
+		if err != nil {
+			return fmt.Errorf("failed to resume server thread: %w", err)
+		}
+	}
+	return nil
+}
+
+// interrupt sends Ctrl+Break to the server's process group, which the server handles as a graceful shutdown.
+// Console events can only be sent to processes on the same console, and SMM has none,
+// so it attaches to the server's console for the duration of the call
+func (t *serverTree) interrupt() error {
+	serverConsoleLock.Lock()
+	defer serverConsoleLock.Unlock()
+
+	attached, _, err := procAttachConsole.Call(uintptr(t.pid))
+	if attached == 0 {
+		return fmt.Errorf("failed to attach to server console: %w", err)
+	}
+	defer procFreeConsole.Call() //nolint:errcheck
+
+	err = windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(t.pid))
+	if err != nil {
+		return fmt.Errorf("failed to interrupt server: %w", err)
+	}
+	return nil
+}
+
+func (t *serverTree) kill() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return nil
+	}
+	err := windows.TerminateJobObject(t.job, 1)
+	if err != nil {
+		return fmt.Errorf("failed to kill server: %w", err)
+	}
+	return nil
+}
+
+// wait blocks until every process of the job exited
+func (t *serverTree) wait() {
+	for {
+		var info jobObjectBasicAccountingInformation
+		err := windows.QueryInformationJobObject(t.job, windows.JobObjectBasicAccountingInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil)
+		if err != nil || info.ActiveProcesses == 0 {
+			return
+		}
+		time.Sleep(serverTreePollInterval)
+	}
+}
+
+func (t *serverTree) close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	_ = windows.CloseHandle(t.job)
+}
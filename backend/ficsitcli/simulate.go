@@ -0,0 +1,225 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/satisfactorymodding/ficsit-cli/cli"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+)
+
+type SimulatedModChange struct {
+	Item           string `json:"item"`
+	CurrentVersion string `json:"currentVersion"`
+	NewVersion     string `json:"newVersion"`
+}
+
+type BlockedMod struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+type ProfileSimulation struct {
+	Profile     string               `json:"profile"`
+	GameVersion int                  `json:"gameVersion"`
+	Targets     []string             `json:"targets"`
+	Blocked     []BlockedMod         `json:"blocked"`
+	Dropped     []SimulatedModChange `json:"dropped"`
+	Downgraded  []SimulatedModChange `json:"downgraded"`
+	Upgraded    []SimulatedModChange `json:"upgraded"`
+	Added       []SimulatedModChange `json:"added"`
+	// Error is set when the mods that are not blocked on their own still cannot be resolved together
+	Error *string `json:"error"`
+}
+
+// SimulateProfile resolves a profile against an arbitrary game version and set of targets,
+// and compares the result to the profile's current lockfile, without modifying any installation.
+// If no targets are provided, the profile's required targets are used
+func (f *ficsitCLI) SimulateProfile(profileName string, gameVersion int, targets []string) (*ProfileSimulation, error) {
+	l := slog.With(slog.String("task", "simulateProfile"), slog.String("profile", profileName), slog.Int("gameVersion", gameVersion))
+
+	profile := f.GetProfile(profileName)
+	if profile == nil {
+		return nil, fmt.Errorf("profile %s not found", profileName)
+	}
+
+	requiredTargets := profile.RequiredTargets
+	if len(targets) > 0 {
+		var err error
+		requiredTargets, err = parseTargets(targets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	currentLockfile, err := f.getProfileLockfile(profileName)
+	if err != nil {
+		l.Error("failed to get current lockfile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get current lockfile: %w", err)
+	}
+	if currentLockfile == nil {
+		currentLockfile = resolver.NewLockfile()
+	}
+
+	simulation := &ProfileSimulation{
+		Profile:     profileName,
+		GameVersion: gameVersion,
+		Targets:     make([]string, 0, len(requiredTargets)),
+		Blocked:     []BlockedMod{},
+		Dropped:     []SimulatedModChange{},
+		Downgraded:  []SimulatedModChange{},
+		Upgraded:    []SimulatedModChange{},
+		Added:       []SimulatedModChange{},
+	}
+	for _, target := range requiredTargets {
+		simulation.Targets = append(simulation.Targets, string(target))
+	}
+
+	res := resolver.NewDependencyResolver(f.ficsitCli.Provider)
+
+	simulatedProfile := &cli.Profile{
+		Name:            "Simulation temp",
+		Mods:            make(map[string]cli.ProfileMod, len(profile.Mods)),
+		RequiredTargets: requiredTargets,
+	}
+	for modReference, modData := range profile.Mods {
+		simulatedProfile.Mods[modReference] = modData
+	}
+
+	// Passing the current lockfile keeps the installed versions when they are still valid, same as apply would
+	newLockfile, err := simulatedProfile.Resolve(res, currentLockfile.Clone(), gameVersion)
+	if err != nil {
+		// Find the mods that cannot be resolved even on their own
+		for modReference, modData := range profile.Mods {
+			if !modData.Enabled {
+				continue
+			}
+			singleModProfile := &cli.Profile{
+				Name: "Simulation temp",
+				Mods: map[string]cli.ProfileMod{
+					modReference: modData,
+				},
+				RequiredTargets: requiredTargets,
+			}
+			_, err := singleModProfile.Resolve(res, nil, gameVersion)
+			if err != nil {
+				simulation.Blocked = append(simulation.Blocked, BlockedMod{
+					Item:   modReference,
+					Reason: err.Error(),
+				})
+				simulatedProfile.SetModEnabled(modReference, false)
+			}
+		}
+		slices.SortFunc(simulation.Blocked, func(a, b BlockedMod) int {
+			return strings.Compare(a.Item, b.Item)
+		})
+
+		newLockfile, err = simulatedProfile.Resolve(res, currentLockfile.Clone(), gameVersion)
+		if err != nil {
+			l.Info("profile cannot be resolved for simulated game version", slog.Any("error", err))
+			errString := err.Error()
+			simulation.Error = &errString
+			return simulation, nil
+		}
+	}
+
+	for modReference, prevLockedMod := range currentLockfile.Mods {
+		newLockedMod, ok := newLockfile.Mods[modReference]
+		if !ok {
+			if slices.ContainsFunc(simulation.Blocked, func(b BlockedMod) bool { return b.Item == modReference }) {
+				continue
+			}
+			simulation.Dropped = append(simulation.Dropped, SimulatedModChange{
+				Item:           modReference,
+				CurrentVersion: prevLockedMod.Version,
+			})
+			continue
+		}
+		change := SimulatedModChange{
+			Item:           modReference,
+			CurrentVersion: prevLockedMod.Version,
+			NewVersion:     newLockedMod.Version,
+		}
+		switch compareVersions(newLockedMod.Version, prevLockedMod.Version) {
+		case -1:
+			simulation.Downgraded = append(simulation.Downgraded, change)
+		case 1:
+			simulation.Upgraded = append(simulation.Upgraded, change)
+		}
+	}
+	for modReference, newLockedMod := range newLockfile.Mods {
+		if _, ok := currentLockfile.Mods[modReference]; !ok {
+			simulation.Added = append(simulation.Added, SimulatedModChange{
+				Item:       modReference,
+				NewVersion: newLockedMod.Version,
+			})
+		}
+	}
+
+	for _, changes := range [][]SimulatedModChange{simulation.Dropped, simulation.Downgraded, simulation.Upgraded, simulation.Added} {
+		slices.SortFunc(changes, func(a, b SimulatedModChange) int {
+			return strings.Compare(a.Item, b.Item)
+		})
+	}
+
+	return simulation, nil
+}
+
+// getProfileLockfile returns the lockfile of a profile, as stored by an installation that uses it.
+// The selected installation is preferred, since it is the one most likely to be up to date
+func (f *ficsitCLI) getProfileLockfile(profileName string) (*resolver.LockFile, error) {
+	installs := make([]*cli.Installation, 0, len(f.ficsitCli.Installations.Installations))
+	if selectedInstallation := f.GetSelectedInstall(); selectedInstallation != nil {
+		installs = append(installs, selectedInstallation)
+	}
+	for _, installPath := range f.GetInstallations() {
+		installs = append(installs, f.GetInstallation(installPath))
+	}
+	for _, installation := range installs {
+		if installation.Profile != profileName {
+			continue
+		}
+		meta, ok := f.installationMetadata.Load(installation.Path)
+		if !ok || meta.State != InstallStateValid {
+			continue
+		}
+		lockfile, err := installation.LockFile(f.ficsitCli)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		if lockfile != nil {
+			return lockfile, nil
+		}
+	}
+	return nil, nil
+}
+
+func parseTargets(targets []string) ([]resolver.TargetName, error) {
+	result := make([]resolver.TargetName, 0, len(targets))
+	for _, target := range targets {
+		targetName := resolver.TargetName(target)
+		if !slices.Contains(allTargetNames, targetName) {
+			return nil, fmt.Errorf("invalid target: %s", target)
+		}
+		if !slices.Contains(result, targetName) {
+			result = append(result, targetName)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result, nil
+}
+
+func compareVersions(a, b string) int {
+	aVersion, errA := semver.NewVersion(a)
+	bVersion, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return aVersion.Compare(bVersion)
+}
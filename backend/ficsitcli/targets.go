@@ -0,0 +1,199 @@
+package ficsitcli
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
+)
+
+type ModTargetSupport struct {
+	Item    string          `json:"item"`
+	Version string          `json:"version"`
+	Targets map[string]bool `json:"targets"`
+	// Missing lists the required targets the locked version does not support
+	Missing []string `json:"missing"`
+}
+
+type ProfileTargetMatrix struct {
+	Profile         string             `json:"profile"`
+	InstallTargets  []string           `json:"installTargets"`
+	ExtraTargets    []string           `json:"extraTargets"`
+	RequiredTargets []string           `json:"requiredTargets"`
+	Mods            []ModTargetSupport `json:"mods"`
+}
+
+var allTargetNames = []resolver.TargetName{
+	resolver.TargetNameWindows,
+	resolver.TargetNameWindowsServer,
+	resolver.TargetNameLinuxServer,
+}
+
+// GetProfileTargetMatrix reports, for every mod locked by a profile, which targets the locked version supports
+func (f *ficsitCLI) GetProfileTargetMatrix(profileName string) (*ProfileTargetMatrix, error) {
+	l := slog.With(slog.String("task", "getProfileTargetMatrix"), slog.String("profile", profileName))
+
+	if f.GetProfile(profileName) == nil {
+		return nil, fmt.Errorf("profile %s not found", profileName)
+	}
+
+	lockfile, err := f.getProfileLockfile(profileName)
+	if err != nil {
+		l.Error("failed to get lockfile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get lockfile: %w", err)
+	}
+	if lockfile == nil {
+		lockfile = resolver.NewLockfile()
+	}
+
+	installTargets, err := f.getProfileInstallTargets(profileName)
+	if err != nil {
+		l.Error("failed to get install targets", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get install targets: %w", err)
+	}
+	extraTargets := f.GetProfileExtraTargets(profileName)
+	requiredTargets := mergeTargets(installTargets, extraTargets)
+
+	matrix := &ProfileTargetMatrix{
+		Profile:         profileName,
+		InstallTargets:  targetsToStrings(installTargets),
+		ExtraTargets:    extraTargets,
+		RequiredTargets: targetsToStrings(requiredTargets),
+		Mods:            make([]ModTargetSupport, 0, len(lockfile.Mods)),
+	}
+
+	for modReference, lockedMod := range lockfile.Mods {
+		support := ModTargetSupport{
+			Item:    modReference,
+			Version: lockedMod.Version,
+			Targets: make(map[string]bool, len(allTargetNames)),
+			Missing: []string{},
+		}
+		for _, target := range allTargetNames {
+			_, ok := lockedMod.Targets[string(target)]
+			support.Targets[string(target)] = ok
+			if !ok && slices.Contains(requiredTargets, target) {
+				support.Missing = append(support.Missing, string(target))
+			}
+		}
+		matrix.Mods = append(matrix.Mods, support)
+	}
+
+	slices.SortFunc(matrix.Mods, func(a, b ModTargetSupport) int {
+		return strings.Compare(a.Item, b.Item)
+	})
+
+	return matrix, nil
+}
+
+func (f *ficsitCLI) GetProfileExtraTargets(profileName string) []string {
+	targets := settings.Settings.ProfileExtraTargets[profileName]
+	if targets == nil {
+		return []string{}
+	}
+	return targets
+}
+
+// SetProfileExtraTargets sets the targets a profile must support even if no installation using it has that target.
+// They are taken into account the next time the profile is applied
+func (f *ficsitCLI) SetProfileExtraTargets(profileName string, targets []string) error {
+	if f.GetProfile(profileName) == nil {
+		return fmt.Errorf("profile %s not found", profileName)
+	}
+
+	parsedTargets, err := parseTargets(targets)
+	if err != nil {
+		return err
+	}
+
+	if settings.Settings.ProfileExtraTargets == nil {
+		settings.Settings.ProfileExtraTargets = map[string][]string{}
+	}
+	if len(parsedTargets) == 0 {
+		delete(settings.Settings.ProfileExtraTargets, profileName)
+	} else {
+		settings.Settings.ProfileExtraTargets[profileName] = targetsToStrings(parsedTargets)
+	}
+	_ = settings.SaveSettings()
+
+	wailsRuntime.EventsEmit(appCommon.AppContext, "profileExtraTargets", settings.Settings.ProfileExtraTargets)
+
+	selectedProfile := f.GetSelectedProfile()
+	if selectedProfile != nil && *selectedProfile == profileName && settings.Settings.QueueAutoStart {
+		return f.Apply()
+	}
+	return nil
+}
+
+func (f *ficsitCLI) renameProfileExtraTargets(oldName string, newName string) {
+	targets, ok := settings.Settings.ProfileExtraTargets[oldName]
+	if !ok {
+		return
+	}
+	delete(settings.Settings.ProfileExtraTargets, oldName)
+	settings.Settings.ProfileExtraTargets[newName] = targets
+	_ = settings.SaveSettings()
+}
+
+func (f *ficsitCLI) deleteProfileExtraTargets(name string) {
+	if _, ok := settings.Settings.ProfileExtraTargets[name]; !ok {
+		return
+	}
+	delete(settings.Settings.ProfileExtraTargets, name)
+	_ = settings.SaveSettings()
+}
+
+// getProfileInstallTargets returns the targets of the valid installations that use the profile
+func (f *ficsitCLI) getProfileInstallTargets(profileName string) ([]resolver.TargetName, error) {
+	targets := make([]resolver.TargetName, 0, len(allTargetNames))
+	for _, installPath := range f.GetInstallations() {
+		meta, ok := f.installationMetadata.Load(installPath)
+		if !ok || meta.State != InstallStateValid {
+			continue
+		}
+		installation := f.GetInstallation(installPath)
+		if installation.Profile != profileName {
+			continue
+		}
+		platform, err := installation.GetPlatform(f.ficsitCli)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get platform: %w", err)
+		}
+		targets = mergeTargets(targets, []string{platform.TargetName})
+	}
+	return targets, nil
+}
+
+// mergeTargets returns the sorted union of the targets, ignoring unknown ones
+func mergeTargets(targets []resolver.TargetName, extra []string) []resolver.TargetName {
+	result := slices.Clone(targets)
+	for _, target := range extra {
+		targetName := resolver.TargetName(target)
+		if !slices.Contains(allTargetNames, targetName) {
+			slog.Warn("ignoring unknown target", slog.String("target", target))
+			continue
+		}
+		if !slices.Contains(result, targetName) {
+			result = append(result, targetName)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+func targetsToStrings(targets []resolver.TargetName) []string {
+	result := make([]string, 0, len(targets))
+	for _, target := range targets {
+		result = append(result, string(target))
+	}
+	return result
+}
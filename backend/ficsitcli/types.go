@@ -14,6 +14,35 @@ const (
 	InstallStateValid   InstallState = "valid"
 )
 
+type SaveModStatus string
+
+const (
+	SaveModStatusAvailable          SaveModStatus = "available"
+	SaveModStatusUnavailable        SaveModStatus = "unavailable"
+	SaveModStatusVersionUnavailable SaveModStatus = "versionUnavailable"
+	SaveModStatusIncompatible       SaveModStatus = "incompatible"
+)
+
+type LaunchIssueKind string
+
+const (
+	LaunchIssueKindMissingMod              LaunchIssueKind = "missingMod"
+	LaunchIssueKindExtraMod                LaunchIssueKind = "extraMod"
+	LaunchIssueKindWrongVersion            LaunchIssueKind = "wrongVersion"
+	LaunchIssueKindMissingSML              LaunchIssueKind = "missingSML"
+	LaunchIssueKindModsWhileDisabled       LaunchIssueKind = "modsWhileDisabled"
+	LaunchIssueKindIncompatibleGameVersion LaunchIssueKind = "incompatibleGameVersion"
+)
+
+type ServerStatus string
+
+const (
+	ServerStatusStopped  ServerStatus = "stopped"
+	ServerStatusRunning  ServerStatus = "running"
+	ServerStatusStopping ServerStatus = "stopping"
+	ServerStatusCrashed  ServerStatus = "crashed"
+)
+
 type installationMetadata struct {
 	State InstallState         `json:"state"`
 	Info  *common.Installation `json:"info"`
@@ -32,6 +61,13 @@ const (
 	ActionImportProfile Action = "importProfile"
 	ActionUpdate        Action = "update"
 	ActionApply         Action = "apply"
+	ActionBisect        Action = "bisect"
+	ActionVerify        Action = "verify"
+	ActionRepair        Action = "repair"
+	ActionPruneCache    Action = "pruneCache"
+	ActionPrefetch      Action = "prefetch"
+	ActionImportConfigs Action = "importConfigs"
+	ActionImportSave    Action = "importSave"
 )
 
 type Progress struct {
@@ -92,4 +128,43 @@ var AllActionTypes = []struct {
 	{ActionImportProfile, "IMPORT_PROFILE"},
 	{ActionUpdate, "UPDATE"},
 	{ActionApply, "APPLY"},
+	{ActionBisect, "BISECT"},
+	{ActionVerify, "VERIFY"},
+	{ActionRepair, "REPAIR"},
+	{ActionPruneCache, "PRUNE_CACHE"},
+	{ActionPrefetch, "PREFETCH"},
+	{ActionImportConfigs, "IMPORT_CONFIGS"},
+	{ActionImportSave, "IMPORT_SAVE"},
+}
+
+var AllSaveModStatuses = []struct {
+	Value  SaveModStatus
+	TSName string
+}{
+	{SaveModStatusAvailable, "AVAILABLE"},
+	{SaveModStatusUnavailable, "UNAVAILABLE"},
+	{SaveModStatusVersionUnavailable, "VERSION_UNAVAILABLE"},
+	{SaveModStatusIncompatible, "INCOMPATIBLE"},
+}
+
+var AllServerStatuses = []struct {
+	Value  ServerStatus
+	TSName string
+}{
+	{ServerStatusStopped, "STOPPED"},
+	{ServerStatusRunning, "RUNNING"},
+	{ServerStatusStopping, "STOPPING"},
+	{ServerStatusCrashed, "CRASHED"},
+}
+
+var AllLaunchIssueKinds = []struct {
+	Value  LaunchIssueKind
+	TSName string
+}{
+	{LaunchIssueKindMissingMod, "MISSING_MOD"},
+	{LaunchIssueKindExtraMod, "EXTRA_MOD"},
+	{LaunchIssueKindWrongVersion, "WRONG_VERSION"},
+	{LaunchIssueKindMissingSML, "MISSING_SML"},
+	{LaunchIssueKindModsWhileDisabled, "MODS_WHILE_DISABLED"},
+	{LaunchIssueKindIncompatibleGameVersion, "INCOMPATIBLE_GAME_VERSION"},
 }
@@ -0,0 +1,308 @@
+package ficsitcli
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ficsitcache "github.com/satisfactorymodding/ficsit-cli/cli/cache"
+	"github.com/satisfactorymodding/ficsit-cli/cli/disk"
+	resolver "github.com/satisfactorymodding/ficsit-resolver"
+
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/utils"
+)
+
+type ModVerification struct {
+	Item     string   `json:"item"`
+	Version  string   `json:"version"`
+	Missing  []string `json:"missing"`
+	Extra    []string `json:"extra"`
+	Modified []string `json:"modified"`
+	// Error is set when the mod could not be verified, for example when its archive could not be downloaded
+	Error *string `json:"error"`
+}
+
+func (m ModVerification) broken() bool {
+	return len(m.Missing) > 0 || len(m.Extra) > 0 || len(m.Modified) > 0
+}
+
+type InstallVerification struct {
+	Install string            `json:"install"`
+	Profile string            `json:"profile"`
+	Target  string            `json:"target"`
+	OK      bool              `json:"ok"`
+	Mods    []ModVerification `json:"mods"`
+}
+
+// VerifyInstall compares the files of the mods installed by SMM to the archives they were extracted from
+func (f *ficsitCLI) VerifyInstall(path string) (*InstallVerification, error) {
+	var verification *InstallVerification
+	err := f.action(ActionVerify, newSimpleItem(path), func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		defer close(taskChannel)
+
+		var err error
+		verification, err = f.verifyInstall(l, path, taskChannel)
+		return err
+	})
+	return verification, err
+}
+
+// RepairInstall re-extracts the mods that failed verification, leaving the intact mods untouched
+func (f *ficsitCLI) RepairInstall(path string) (*InstallVerification, error) {
+	var verification *InstallVerification
+	err := f.action(ActionRepair, newSimpleItem(path), func(l *slog.Logger, taskChannel chan<- taskUpdate) error {
+		defer close(taskChannel)
+
+		var err error
+		verification, err = f.verifyInstall(l, path, taskChannel)
+		if err != nil {
+			return err
+		}
+		if verification.OK {
+			return nil
+		}
+
+		err = f.checkGameNotRunning(path)
+		if err != nil {
+			return err
+		}
+
+		installation := f.GetInstallation(path)
+		d, err := installation.GetDisk()
+		if err != nil {
+			l.Error("failed to get disk", slog.Any("error", err))
+			return fmt.Errorf("failed to get disk: %w", err)
+		}
+
+		// Same as an apply, the mod files of a running server cannot be replaced
+		restartServers, err := f.stopServersForApply(l, path)
+		if err != nil {
+			return err
+		}
+
+		modsDirectory := filepath.Join(installation.BasePath(), "FactoryGame", "Mods")
+
+		for _, mod := range verification.Mods {
+			if !mod.broken() {
+				continue
+			}
+			l.Info("removing broken mod", slog.String("mod", mod.Item), slog.String("version", mod.Version))
+			// Removing the whole directory also removes the .smm hash file, so the next install extracts it again
+			err := d.Remove(filepath.Join(modsDirectory, mod.Item))
+			if err != nil {
+				l.Error("failed to remove broken mod", slog.String("mod", mod.Item), slog.Any("error", err))
+				return fmt.Errorf("failed to remove broken mod %s: %w", mod.Item, err)
+			}
+		}
+
+		installChannel := forwardInstallUpdates(verification.Target, taskChannel)
+		installErr := installation.Install(f.ficsitCli, installChannel)
+		if installErr != nil {
+			l.Error("failed to reinstall mods", slog.Any("error", installErr))
+			var solvingError resolver.DependencyResolverError
+			if errors.As(installErr, &solvingError) {
+				return solvingError
+			}
+			return installErr //nolint:wrapcheck
+		}
+
+		f.evictCache(l)
+		f.restartServersAfterApply(l, restartServers)
+
+		verification, err = f.verifyInstall(l, path, taskChannel)
+		return err
+	})
+	return verification, err
+}
+
+func (f *ficsitCLI) verifyInstall(l *slog.Logger, path string, taskChannel chan<- taskUpdate) (*InstallVerification, error) {
+	installation := f.GetInstallation(path)
+	if installation == nil {
+		return nil, fmt.Errorf("installation %s not found", path)
+	}
+	meta, ok := f.installationMetadata.Load(path)
+	if !ok || meta.State != InstallStateValid {
+		return nil, fmt.Errorf("installation %s is not valid", path)
+	}
+
+	l = l.With(slog.String("install", utils.RedactPath(path)), slog.String("profile", installation.Profile))
+
+	platform, err := installation.GetPlatform(f.ficsitCli)
+	if err != nil {
+		l.Error("failed to get platform", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get platform: %w", err)
+	}
+
+	d, err := installation.GetDisk()
+	if err != nil {
+		l.Error("failed to get disk", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get disk: %w", err)
+	}
+
+	verification := &InstallVerification{
+		Install: path,
+		Profile: installation.Profile,
+		Target:  platform.TargetName,
+		OK:      true,
+		Mods:    []ModVerification{},
+	}
+
+	if installation.Vanilla {
+		return verification, nil
+	}
+
+	lockfile, err := installation.LockFile(f.ficsitCli)
+	if err != nil {
+		l.Error("failed to read lockfile", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	if lockfile == nil {
+		return verification, nil
+	}
+
+	modsDirectory := filepath.Join(installation.BasePath(), "FactoryGame", "Mods")
+
+	modReferences := make([]string, 0, len(lockfile.Mods))
+	for modReference := range lockfile.Mods {
+		modReferences = append(modReferences, modReference)
+	}
+	slices.Sort(modReferences)
+
+	for i, modReference := range modReferences {
+		lockedMod := lockfile.Mods[modReference]
+		target, ok := lockedMod.Targets[platform.TargetName]
+		// Same as the install, mods without this target or without a link are not installed by SMM
+		if ok && target.Link != "" {
+			modVerification := verifyMod(d, modsDirectory, modReference, lockedMod.Version, platform.TargetName, target)
+			if modVerification.Error != nil {
+				l.Warn("failed to verify mod", slog.String("mod", modReference), slog.String("error", *modVerification.Error))
+			}
+			if modVerification.broken() {
+				l.Info("mod failed verification", slog.String("mod", modReference), slog.Int("missing", len(modVerification.Missing)), slog.Int("extra", len(modVerification.Extra)), slog.Int("modified", len(modVerification.Modified)))
+				verification.OK = false
+			}
+			verification.Mods = append(verification.Mods, modVerification)
+		}
+
+		taskChannel <- taskUpdate{
+			taskName: fmt.Sprintf("%s:verify", platform.TargetName),
+			progress: utils.Progress{
+				Current: int64(i + 1),
+				Total:   int64(len(modReferences)),
+			},
+		}
+	}
+
+	return verification, nil
+}
+
+func verifyMod(d disk.Disk, modsDirectory string, modReference string, version string, targetName string, target resolver.LockedModTarget) ModVerification {
+	result := ModVerification{
+		Item:     modReference,
+		Version:  version,
+		Missing:  []string{},
+		Extra:    []string{},
+		Modified: []string{},
+	}
+	setError := func(err error) ModVerification {
+		errString := err.Error()
+		result.Error = &errString
+		return result
+	}
+
+	// Downloads the archive if it is no longer cached
+	archive, size, err := ficsitcache.DownloadOrCache(modReference+"_"+version+"_"+targetName+".zip", target.Hash, target.Link, nil, nil)
+	if err != nil {
+		return setError(fmt.Errorf("failed to get archive: %w", err))
+	}
+	defer archive.Close()
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return setError(fmt.Errorf("failed to read archive: %w", err))
+	}
+
+	modDirectory := filepath.Join(modsDirectory, modReference)
+
+	installedFiles, err := listDiskFiles(d, modDirectory, "")
+	if err != nil {
+		return setError(fmt.Errorf("failed to list installed files: %w", err))
+	}
+
+	installed := make(map[string]bool, len(installedFiles))
+	for _, file := range installedFiles {
+		installed[file] = true
+	}
+
+	expectedFiles := make(map[string]bool, len(reader.File))
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		expectedFiles[file.Name] = true
+
+		if !installed[file.Name] {
+			result.Missing = append(result.Missing, file.Name)
+			continue
+		}
+
+		data, err := d.Read(filepath.Join(modDirectory, file.Name))
+		if err != nil {
+			return setError(fmt.Errorf("failed to read %s: %w", file.Name, err))
+		}
+		if uint64(len(data)) != file.UncompressedSize64 || crc32.ChecksumIEEE(data) != file.CRC32 {
+			result.Modified = append(result.Modified, file.Name)
+		}
+	}
+
+	for _, file := range installedFiles {
+		if file == ".smm" {
+			continue
+		}
+		if !expectedFiles[file] {
+			result.Extra = append(result.Extra, file)
+		}
+	}
+
+	slices.Sort(result.Missing)
+	slices.Sort(result.Modified)
+	slices.Sort(result.Extra)
+
+	return result
+}
+
+// listDiskFiles returns the paths of all the files in a directory, relative to it and separated by /, like in zip archives
+func listDiskFiles(d disk.Disk, root string, prefix string) ([]string, error) {
+	exists, err := d.Exists(filepath.Join(root, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if %s exists: %w", prefix, err)
+	}
+	if !exists {
+		return []string{}, nil
+	}
+
+	entries, err := d.ReadDir(filepath.Join(root, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", prefix, err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimPrefix(prefix+"/"+entry.Name(), "/")
+		if entry.IsDir() {
+			subFiles, err := listDiskFiles(d, root, name)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, subFiles...)
+			continue
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
@@ -3,17 +3,16 @@ package ficsitcli
 import (
 	"fmt"
 	"log/slog"
-	"slices"
 	"sync"
-	"time"
 
-	"github.com/mitchellh/go-ps"
 	"github.com/puzpuzpuz/xsync/v3"
 	"github.com/satisfactorymodding/ficsit-cli/cli"
 	"github.com/satisfactorymodding/ficsit-cli/cli/provider"
+	"github.com/spf13/viper"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 
 	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/credentials"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/settings"
 )
@@ -24,6 +23,14 @@ type ficsitCLI struct {
 	installFindErrors    []error
 	isGameRunning        bool
 	actionMutex          sync.Mutex
+	bisect               *bisectSession
+	connectivity         connectivity
+	gameSessions         gameSessions
+	logTails             logTails
+	servers              serverProcesses
+	remoteHealth         remoteHealth
+	credentials          *credentials.Store
+	installCacheKeys     installCacheKeys
 }
 
 var FicsitCLI *ficsitCLI
@@ -36,13 +43,38 @@ func Init() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize ficsit-cli: %w", err)
 	}
-	ficsitCli.Provider.(*provider.MixedProvider).Offline = settings.Settings.Offline
+	// The repository provider replaces the ficsit-cli online provider, to support the configured endpoints
+	repository := newRepositoryProvider(func(err error) {
+		if FicsitCLI != nil {
+			FicsitCLI.markAPIUnreachable(err)
+		}
+	})
+
+	localModsProvider, err := newLocalModsProvider(repository, provider.NewLocalProvider(), settings.Settings.Offline)
+	if err != nil {
+		return fmt.Errorf("failed to load local mods: %w", err)
+	}
+	ficsitCli.Provider = localModsProvider
 
 	FicsitCLI = &ficsitCLI{ficsitCli: ficsitCli, installationMetadata: xsync.NewMapOf[string, installationMetadata]()}
+	FicsitCLI.gameSessions.running = make(map[int32]*runningGameSession)
+	FicsitCLI.gameSessions.history = loadGameSessionHistory()
+	FicsitCLI.logTails.tails = make(map[string]*logTail)
+	FicsitCLI.servers.servers = make(map[string]*serverProcess)
+	FicsitCLI.remoteHealth.servers = make(map[string]*RemoteServerHealth)
+	FicsitCLI.remoteHealth.checking = make(map[string]bool)
+	FicsitCLI.credentials, err = credentials.Open(viper.GetString("smm-local-dir"))
+	if err != nil {
+		// The store stays locked, so only the remote installs that use stored credentials are unavailable
+		slog.Error("failed to load credential store", slog.Any("error", err))
+	}
+	FicsitCLI.migrateRemoteCredentials()
+	FicsitCLI.attachRemoteDisks()
 	err = FicsitCLI.initInstallations()
 	if err != nil {
 		return fmt.Errorf("failed to initialize installations: %w", err)
 	}
+	FicsitCLI.resumeBisect()
 
 	if settings.SMM2SelectedProfile != nil {
 		for _, install := range FicsitCLI.ficsitCli.Installations.Installations {
@@ -65,34 +97,6 @@ func Init() error {
 	return nil
 }
 
-// With and without `.exe` variants in case it is missing on Linux
-var executableNames = []string{
-	"FactoryGame-Win64-Shipping.exe", "FactoryGame-Win64-Shipping",
-	"FactoryGameSteam-Win64-Shipping.exe", "FactoryGameSteam-Win64-Shipping",
-	"FactoryGameEGS-Win64-Shipping.exe", "FactoryGameEGS-Win64-Shipping",
-}
-
-func (f *ficsitCLI) StartGameRunningWatcher() {
-	gameRunningTicker := time.NewTicker(5 * time.Second)
-	go func() {
-		for range gameRunningTicker.C {
-			processes, err := ps.Processes()
-			if err != nil {
-				slog.Error("failed to get processes", slog.Any("error", err))
-				continue
-			}
-			f.isGameRunning = false
-			for _, process := range processes {
-				if slices.Contains(executableNames, process.Executable()) {
-					f.isGameRunning = true
-					break
-				}
-			}
-			wailsRuntime.EventsEmit(appCommon.AppContext, "isGameRunning", f.isGameRunning)
-		}
-	}()
-}
-
 // GetProgress exists only to ensure the Progress type is exported to typescript. It returns nil
 func (f *ficsitCLI) GetProgress() *Progress {
 	return nil
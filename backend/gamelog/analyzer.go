@@ -0,0 +1,430 @@
+package gamelog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type FindingKind string
+
+const (
+	FindingKindFatalError       FindingKind = "fatalError"
+	FindingKindAssertion        FindingKind = "assertion"
+	FindingKindPluginLoadFailed FindingKind = "pluginLoadFailed"
+	FindingKindMissingSML       FindingKind = "missingSML"
+	FindingKindVersionMismatch  FindingKind = "versionMismatch"
+)
+
+var AllFindingKinds = []struct {
+	Value  FindingKind
+	TSName string
+}{
+	{FindingKindFatalError, "FATAL_ERROR"},
+	{FindingKindAssertion, "ASSERTION"},
+	{FindingKindPluginLoadFailed, "PLUGIN_LOAD_FAILED"},
+	{FindingKindMissingSML, "MISSING_SML"},
+	{FindingKindVersionMismatch, "VERSION_MISMATCH"},
+}
+
+// Mod is a mod of the lockfile the log is analyzed against
+type Mod struct {
+	Reference string
+	Version   string
+	// Modules are the names of the binaries of the mod, without the FactoryGame- prefix and platform suffix.
+	// The mod reference is always treated as one of its modules
+	Modules []string
+}
+
+type Frame struct {
+	Module   string `json:"module"`
+	Function string `json:"function"`
+	Source   string `json:"source"`
+	// Mod is empty if the frame is not in a mod
+	Mod string `json:"mod"`
+}
+
+type Finding struct {
+	Kind FindingKind `json:"kind"`
+	// Line is 1-based, 0 for findings that are not about a specific line
+	Line      int      `json:"line"`
+	Message   string   `json:"message"`
+	Mods      []string `json:"mods"`
+	Callstack []Frame  `json:"callstack"`
+}
+
+type Suspect struct {
+	Mod     string   `json:"mod"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type Analysis struct {
+	Findings []Finding `json:"findings"`
+	// Suspects are ordered from most to least likely
+	Suspects   []Suspect `json:"suspects"`
+	ErrorCount int       `json:"errorCount"`
+	SMLVersion string    `json:"smlVersion"`
+}
+
+const (
+	scorePluginLoadFailed = 100
+	scoreVersionMismatch  = 60
+	scoreFirstModFrame    = 50
+	scoreModInMessage     = 40
+	scoreOtherModFrame    = 10
+	scoreErrorLine        = 1
+	maxErrorLinesScore    = 10
+
+	// Stop reading absurdly long lines instead of keeping them in memory
+	maxLineLength = 64 * 1024
+)
+
+var (
+	linePrefixRegex      = regexp.MustCompile(`^\[[^\]]*\]\[\s*\d+\]`)
+	categoryRegex        = regexp.MustCompile(`^(Log\w+): (?:(Error|Fatal|Warning): )?`)
+	callstackRegex       = regexp.MustCompile(`\[Callstack\]\s+(?:0x[0-9a-fA-F]+\s+)?(.*)$`)
+	frameRegex           = regexp.MustCompile(`^([^!\s]+)!(.*?)(?:\s+\[([^\]]*)\])?$`)
+	criticalErrorRegex   = regexp.MustCompile(`=== Critical error: ===`)
+	fatalErrorRegex      = regexp.MustCompile(`(?:Fatal error:|Unhandled Exception:|appError called:)\s*(.*)$`)
+	assertionRegex       = regexp.MustCompile(`(?:Assertion failed:|Ensure condition failed:)\s*(.*)$`)
+	pluginLoadRegex      = regexp.MustCompile(`(?:Plugin '([^']+)' failed to load because (.*)|Unable to load plugin '([^']+)'.*)$`)
+	missingDependencyRe  = regexp.MustCompile(`dependency '([^']+)'`)
+	versionMismatchRegex = regexp.MustCompile(`(?i)(?:different engine version|incompatible (?:engine|game) version|requires (?:game|SML) version)`)
+	moduleFileRegex      = regexp.MustCompile(`(?i)(?:lib)?(?:FactoryGame|FactoryGameSteam|FactoryGameEGS|FactoryServer|UnrealEditor)-(\w+?)(?:-(?:Win64|Linux|LinuxArm64)-Shipping)?\.(?:dll|so|pdb)`)
+	modSourceRegex       = regexp.MustCompile(`(?i)[\\/]Mods[\\/](\w+)[\\/]`)
+	quotedNameRegex      = regexp.MustCompile(`'(\w+)'`)
+	smlVersionRegex      = regexp.MustCompile(`Satisfactory Mod Loader v(\S+)`)
+)
+
+type analyzer struct {
+	// modules maps lowercase module names to mod references
+	modules    map[string]string
+	references map[string]string
+	mods       []Mod
+
+	analysis    Analysis
+	scores      map[string]*Suspect
+	errorLines  map[string]int
+	crash       *Finding
+	inCallstack bool
+	// mismatch is kept open while the following lines list the mismatched modules
+	mismatch *Finding
+}
+
+// Analyze reads a FactoryGame.log, and finds the errors in it and the mods that most likely caused them
+func Analyze(r io.Reader, mods []Mod) (*Analysis, error) {
+	a := &analyzer{
+		modules:    make(map[string]string),
+		references: make(map[string]string),
+		mods:       mods,
+		scores:     make(map[string]*Suspect),
+		errorLines: make(map[string]int),
+		analysis: Analysis{
+			Findings: []Finding{},
+			Suspects: []Suspect{},
+		},
+	}
+	for _, mod := range mods {
+		a.references[strings.ToLower(mod.Reference)] = mod.Reference
+		a.modules[strings.ToLower(mod.Reference)] = mod.Reference
+		for _, module := range mod.Modules {
+			a.modules[strings.ToLower(module)] = mod.Reference
+		}
+	}
+
+	reader := bufio.NewReader(r)
+	lineNumber := 0
+	for {
+		line, err := readLine(reader)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read log: %w", err)
+		}
+		if line != "" || err == nil {
+			lineNumber++
+			a.analyzeLine(lineNumber, line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	a.endCrash()
+	a.endMismatch()
+	a.checkSML()
+
+	for mod, count := range a.errorLines {
+		a.addScore(mod, min(count*scoreErrorLine, maxErrorLinesScore), fmt.Sprintf("%d error lines in its log category", count))
+	}
+
+	for _, suspect := range a.scores {
+		a.analysis.Suspects = append(a.analysis.Suspects, *suspect)
+	}
+	slices.SortFunc(a.analysis.Suspects, func(a, b Suspect) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+		return strings.Compare(a.Mod, b.Mod)
+	})
+
+	return &a.analysis, nil
+}
+
+// readLine reads a line without the line ending, truncated to maxLineLength
+func readLine(reader *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return sb.String(), err //nolint:wrapcheck
+		}
+		if sb.Len() < maxLineLength {
+			sb.Write(chunk[:min(len(chunk), maxLineLength-sb.Len())])
+		}
+		if !isPrefix {
+			return sb.String(), nil
+		}
+	}
+}
+
+func (a *analyzer) analyzeLine(lineNumber int, line string) {
+	line = linePrefixRegex.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+
+	if match := callstackRegex.FindStringSubmatch(line); match != nil {
+		a.endMismatch()
+		a.addFrame(lineNumber, match[1])
+		return
+	}
+	a.inCallstack = false
+
+	if match := smlVersionRegex.FindStringSubmatch(line); match != nil {
+		a.analysis.SMLVersion = match[1]
+	}
+
+	category := categoryRegex.FindStringSubmatch(line)
+	message := line
+	if category != nil {
+		message = line[len(category[0]):]
+		if category[2] == "Error" || category[2] == "Fatal" {
+			a.analysis.ErrorCount++
+			if mod, ok := a.references[strings.ToLower(strings.TrimPrefix(category[1], "Log"))]; ok {
+				a.errorLines[mod]++
+			}
+		}
+	}
+
+	switch {
+	case criticalErrorRegex.MatchString(line):
+		a.startCrash(lineNumber, FindingKindFatalError, "")
+	case assertionRegex.MatchString(line):
+		assertion := assertionRegex.FindStringSubmatch(line)[1]
+		if a.crash != nil && a.crash.Message == "" {
+			a.crash.Kind = FindingKindAssertion
+			a.crash.Message = assertion
+			a.crash.Mods = a.modsInText(assertion)
+			return
+		}
+		a.startCrash(lineNumber, FindingKindAssertion, assertion)
+	case fatalErrorRegex.MatchString(line):
+		fatal := fatalErrorRegex.FindStringSubmatch(line)[1]
+		if a.crash != nil && a.crash.Message == "" {
+			a.crash.Message = fatal
+			a.crash.Mods = a.modsInText(fatal)
+			return
+		}
+		a.startCrash(lineNumber, FindingKindFatalError, fatal)
+	case pluginLoadRegex.MatchString(line):
+		a.addPluginLoadFailure(lineNumber, message)
+	case versionMismatchRegex.MatchString(line):
+		a.endMismatch()
+		a.mismatch = &Finding{Kind: FindingKindVersionMismatch, Line: lineNumber, Message: message, Mods: a.modsInText(message)}
+		for _, mod := range a.mismatch.Mods {
+			a.addScore(mod, scoreVersionMismatch, fmt.Sprintf("version mismatch on line %d", lineNumber))
+		}
+		return
+	default:
+		// The lines after "The following modules are missing or built with a different engine version" list the modules
+		if a.mismatch != nil && category == nil && strings.TrimSpace(line) != "" {
+			for _, mod := range a.modsInText(line) {
+				if !slices.Contains(a.mismatch.Mods, mod) {
+					a.mismatch.Mods = append(a.mismatch.Mods, mod)
+					a.addScore(mod, scoreVersionMismatch, fmt.Sprintf("version mismatch on line %d", a.mismatch.Line))
+				}
+			}
+			a.mismatch.Message += "\n" + strings.TrimSpace(line)
+			return
+		}
+	}
+	a.endMismatch()
+}
+
+func (a *analyzer) endMismatch() {
+	if a.mismatch == nil {
+		return
+	}
+	a.addFinding(*a.mismatch)
+	a.mismatch = nil
+}
+
+func (a *analyzer) startCrash(lineNumber int, kind FindingKind, message string) {
+	a.endCrash()
+	a.crash = &Finding{
+		Kind:      kind,
+		Line:      lineNumber,
+		Message:   message,
+		Mods:      a.modsInText(message),
+		Callstack: []Frame{},
+	}
+}
+
+func (a *analyzer) endCrash() {
+	if a.crash == nil {
+		return
+	}
+	crash := *a.crash
+	a.crash = nil
+	a.inCallstack = false
+
+	for _, mod := range crash.Mods {
+		a.addScore(mod, scoreModInMessage, fmt.Sprintf("mentioned by the %s on line %d", crash.Kind, crash.Line))
+	}
+
+	// The frame closest to the top that is in a mod is most likely at fault,
+	// the frames below it only called into the faulting code
+	seen := make(map[string]bool)
+	for i, frame := range crash.Callstack {
+		if frame.Mod == "" || seen[frame.Mod] {
+			continue
+		}
+		if len(seen) == 0 {
+			a.addScore(frame.Mod, scoreFirstModFrame, fmt.Sprintf("topmost mod frame (#%d) of the callstack of the %s on line %d", i, crash.Kind, crash.Line))
+		} else {
+			a.addScore(frame.Mod, scoreOtherModFrame, fmt.Sprintf("in the callstack of the %s on line %d", crash.Kind, crash.Line))
+		}
+		seen[frame.Mod] = true
+	}
+
+	a.addFinding(crash)
+}
+
+func (a *analyzer) addFrame(lineNumber int, text string) {
+	if a.crash == nil || (!a.inCallstack && len(a.crash.Callstack) > 0) {
+		// A callstack without a known error before it is still a crash
+		a.startCrash(lineNumber, FindingKindFatalError, "")
+	}
+	a.inCallstack = true
+
+	frame := Frame{Function: strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "[]"))}
+	if match := frameRegex.FindStringSubmatch(text); match != nil {
+		frame.Module = match[1]
+		frame.Function = match[2]
+		frame.Source = match[3]
+	}
+	if mods := a.modsInText(frame.Module + " " + frame.Source); len(mods) > 0 {
+		frame.Mod = mods[0]
+	}
+	a.crash.Callstack = append(a.crash.Callstack, frame)
+}
+
+func (a *analyzer) addPluginLoadFailure(lineNumber int, message string) {
+	match := pluginLoadRegex.FindStringSubmatch(message)
+	plugin := match[1]
+	if plugin == "" {
+		plugin = match[3]
+	}
+
+	kind := FindingKindPluginLoadFailed
+	// "Unable to load plugin" has no separate reason, the dependency is named anywhere after the plugin
+	if dependency := missingDependencyRe.FindStringSubmatch(match[0]); dependency != nil && strings.EqualFold(dependency[1], "SML") {
+		kind = FindingKindMissingSML
+	}
+
+	var mods []string
+	if mod, ok := a.modules[strings.ToLower(plugin)]; ok {
+		mods = append(mods, mod)
+		// A missing SML is not the fault of the mod that needs it
+		if kind == FindingKindPluginLoadFailed {
+			a.addScore(mod, scorePluginLoadFailed, fmt.Sprintf("failed to load on line %d", lineNumber))
+		}
+	}
+	a.addFinding(Finding{Kind: kind, Line: lineNumber, Message: message, Mods: mods})
+}
+
+// checkSML reports a missing SML when the lockfile has mods, but SML is not part of it.
+// An older SML than the one in the lockfile means the log is from before the last apply
+func (a *analyzer) checkSML() {
+	if len(a.mods) == 0 {
+		return
+	}
+	idx := slices.IndexFunc(a.mods, func(mod Mod) bool { return mod.Reference == "SML" })
+	if idx == -1 {
+		a.addFinding(Finding{Kind: FindingKindMissingSML, Message: "SML is not installed, but other mods are", Mods: []string{}})
+		return
+	}
+	sml := a.mods[idx]
+	if a.analysis.SMLVersion != "" && sml.Version != "" && strings.TrimPrefix(a.analysis.SMLVersion, "v") != sml.Version {
+		a.addFinding(Finding{
+			Kind:    FindingKindVersionMismatch,
+			Message: fmt.Sprintf("the log was written by SML %s, but SML %s is installed, the log might be from before the mods were last changed", a.analysis.SMLVersion, sml.Version),
+			Mods:    []string{},
+		})
+	}
+}
+
+// modsInText returns the mods whose binaries, source paths or quoted names appear in the text
+func (a *analyzer) modsInText(text string) []string {
+	mods := []string{}
+	add := func(mod string) {
+		if !slices.Contains(mods, mod) {
+			mods = append(mods, mod)
+		}
+	}
+	for _, match := range moduleFileRegex.FindAllStringSubmatch(text, -1) {
+		if mod, ok := a.modules[strings.ToLower(match[1])]; ok {
+			add(mod)
+		}
+	}
+	for _, match := range modSourceRegex.FindAllStringSubmatch(text, -1) {
+		if mod, ok := a.references[strings.ToLower(match[1])]; ok {
+			add(mod)
+		}
+	}
+	for _, match := range quotedNameRegex.FindAllStringSubmatch(text, -1) {
+		if mod, ok := a.modules[strings.ToLower(match[1])]; ok {
+			add(mod)
+		}
+	}
+	return mods
+}
+
+func (a *analyzer) addFinding(finding Finding) {
+	if finding.Mods == nil {
+		finding.Mods = []string{}
+	}
+	if finding.Callstack == nil {
+		finding.Callstack = []Frame{}
+	}
+	a.analysis.Findings = append(a.analysis.Findings, finding)
+}
+
+func (a *analyzer) addScore(mod string, score int, reason string) {
+	suspect, ok := a.scores[mod]
+	if !ok {
+		suspect = &Suspect{Mod: mod, Reasons: []string{}}
+		a.scores[mod] = suspect
+	}
+	suspect.Score += score
+	suspect.Reasons = append(suspect.Reasons, reason)
+}
+
+// ModuleName returns the module name of a game or mod binary file name, such as FactoryGame-SML-Win64-Shipping.dll
+func ModuleName(fileName string) (string, bool) {
+	match := moduleFileRegex.FindStringSubmatch(fileName)
+	if match == nil || match[0] != fileName {
+		return "", false
+	}
+	return match[1], true
+}
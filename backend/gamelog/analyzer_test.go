@@ -0,0 +1,227 @@
+package gamelog
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var testMods = []Mod{
+	{Reference: "SML", Version: "3.8.0"},
+	{Reference: "RefinedPower", Version: "3.2.1"},
+	{Reference: "PowerSuit", Version: "1.0.0", Modules: []string{"PowerSuitModule"}},
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name          string
+		log           string
+		mods          []Mod
+		findings      []FindingKind
+		findingMods   [][]string
+		topSuspect    string
+		errorCount    int
+		smlVersion    string
+		callstackMods []string
+	}{
+		{
+			name:        "empty log",
+			log:         "",
+			mods:        testMods,
+			findings:    []FindingKind{},
+			findingMods: [][]string{},
+		},
+		{
+			name:        "mods without SML",
+			log:         "LogInit: Display: Starting game",
+			mods:        []Mod{{Reference: "RefinedPower", Version: "3.2.1"}},
+			findings:    []FindingKind{FindingKindMissingSML},
+			findingMods: [][]string{{}},
+		},
+		{
+			name: "crash with mod frames",
+			log: strings.Join([]string{
+				"[2024.01.01-00.00.00:000][  0]LogInit: Satisfactory Mod Loader v3.8.0",
+				"[2024.01.01-00.00.01:000][  0]LogWindows: Error: === Critical error: ===",
+				"[2024.01.01-00.00.01:000][  0]LogWindows: Error: Fatal error: [File:Unknown] [Line: 0]",
+				"[2024.01.01-00.00.01:000][  0]LogWindows: Error: [Callstack] 0x00007ff6 FactoryGame-Core-Win64-Shipping.dll!UObject::Tick() []",
+				"[2024.01.01-00.00.01:000][  0]LogWindows: Error: [Callstack] 0x00007ff7 FactoryGame-RefinedPower-Win64-Shipping.dll!ARPReactor::Tick() [C:\\Mods\\RefinedPower\\Source\\Reactor.cpp:42]",
+				"[2024.01.01-00.00.01:000][  0]LogWindows: Error: [Callstack] 0x00007ff8 FactoryGame-PowerSuitModule-Win64-Shipping.dll!UPowerSuit::Update() []",
+			}, "\n"),
+			mods:          testMods,
+			findings:      []FindingKind{FindingKindFatalError},
+			findingMods:   [][]string{{}},
+			topSuspect:    "RefinedPower",
+			errorCount:    2,
+			smlVersion:    "3.8.0",
+			callstackMods: []string{"", "RefinedPower", "PowerSuit"},
+		},
+		{
+			name:        "assertion mentioning a mod",
+			log:         "LogCore: Error: Assertion failed: IsValid() [File:D:\\Mods\\PowerSuit\\Source\\Suit.cpp] [Line: 12]",
+			mods:        testMods,
+			findings:    []FindingKind{FindingKindAssertion},
+			findingMods: [][]string{{"PowerSuit"}},
+			topSuspect:  "PowerSuit",
+			errorCount:  1,
+		},
+		{
+			name:        "plugin failed to load",
+			log:         "LogPluginManager: Error: Plugin 'RefinedPower' failed to load because module 'RefinedPower' could not be loaded.",
+			mods:        testMods,
+			findings:    []FindingKind{FindingKindPluginLoadFailed},
+			findingMods: [][]string{{"RefinedPower"}},
+			topSuspect:  "RefinedPower",
+			errorCount:  1,
+		},
+		{
+			name:        "plugin missing SML",
+			log:         "LogPluginManager: Error: Unable to load plugin 'PowerSuit'. Unable to find dependency 'SML'.",
+			mods:        testMods,
+			findings:    []FindingKind{FindingKindMissingSML},
+			findingMods: [][]string{{"PowerSuit"}},
+			errorCount:  1,
+		},
+		{
+			name:        "plugin missing another dependency",
+			log:         "LogPluginManager: Error: Plugin 'PowerSuit' failed to load because its dependency 'RefinedPower' is disabled.",
+			mods:        testMods,
+			findings:    []FindingKind{FindingKindPluginLoadFailed},
+			findingMods: [][]string{{"PowerSuit"}},
+			topSuspect:  "PowerSuit",
+			errorCount:  1,
+		},
+		{
+			name: "version mismatch listing modules",
+			log: strings.Join([]string{
+				"LogInit: Warning: The following modules are missing or built with a different engine version:",
+				"  FactoryGame-RefinedPower-Win64-Shipping.dll",
+				"  FactoryGame-PowerSuitModule-Win64-Shipping.dll",
+				"LogInit: Display: Done",
+			}, "\n"),
+			mods:        testMods,
+			findings:    []FindingKind{FindingKindVersionMismatch},
+			findingMods: [][]string{{"RefinedPower", "PowerSuit"}},
+			topSuspect:  "PowerSuit",
+		},
+		{
+			name:        "log from an older SML",
+			log:         "LogInit: Satisfactory Mod Loader v3.7.0",
+			mods:        testMods,
+			findings:    []FindingKind{FindingKindVersionMismatch},
+			findingMods: [][]string{{}},
+			smlVersion:  "3.7.0",
+		},
+		{
+			name:        "error lines in a mod category",
+			log:         "LogRefinedPower: Error: reactor exploded\nLogRefinedPower: Error: reactor exploded again\nLogRefinedPower: Warning: not an error",
+			mods:        testMods,
+			findings:    []FindingKind{},
+			findingMods: [][]string{},
+			topSuspect:  "RefinedPower",
+			errorCount:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := Analyze(strings.NewReader(tt.log), tt.mods)
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+
+			kinds := make([]FindingKind, 0, len(analysis.Findings))
+			mods := make([][]string, 0, len(analysis.Findings))
+			for _, finding := range analysis.Findings {
+				kinds = append(kinds, finding.Kind)
+				mods = append(mods, finding.Mods)
+			}
+			if !slices.Equal(kinds, tt.findings) {
+				t.Errorf("finding kinds = %v, want %v", kinds, tt.findings)
+			}
+			if !slices.EqualFunc(mods, tt.findingMods, slices.Equal[[]string]) {
+				t.Errorf("finding mods = %v, want %v", mods, tt.findingMods)
+			}
+
+			topSuspect := ""
+			if len(analysis.Suspects) > 0 {
+				topSuspect = analysis.Suspects[0].Mod
+			}
+			if topSuspect != tt.topSuspect {
+				t.Errorf("top suspect = %q, want %q (suspects %v)", topSuspect, tt.topSuspect, analysis.Suspects)
+			}
+			if analysis.ErrorCount != tt.errorCount {
+				t.Errorf("error count = %d, want %d", analysis.ErrorCount, tt.errorCount)
+			}
+			if analysis.SMLVersion != tt.smlVersion {
+				t.Errorf("SML version = %q, want %q", analysis.SMLVersion, tt.smlVersion)
+			}
+
+			if tt.callstackMods != nil {
+				frameMods := make([]string, 0, len(analysis.Findings[0].Callstack))
+				for _, frame := range analysis.Findings[0].Callstack {
+					frameMods = append(frameMods, frame.Mod)
+				}
+				if !slices.Equal(frameMods, tt.callstackMods) {
+					t.Errorf("callstack mods = %v, want %v", frameMods, tt.callstackMods)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		text     string
+		category string
+		level    Level
+	}{
+		{"Log file open, 01/01/24 00:00:00", "", LevelLog},
+		{"LogInit: Build: ++FactoryGame+rel-main-1.0.0-CL-365306", "LogInit", LevelLog},
+		{"[2024.01.01-00.00.00:000][  0]LogWindows: Error: something failed", "LogWindows", LevelError},
+		{"LogStreaming: Warning: missing package", "LogStreaming", LevelWarning},
+		{"LogSML: Display: loaded", "LogSML", LevelDisplay},
+		{"LogNet: Verbose: packet", "LogNet", LevelVerbose},
+		{"LogNet: VeryVerbose: byte", "LogNet", LevelVeryVerbose},
+		{"LogCore: Fatal: out of memory", "LogCore", LevelFatal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			line := ParseLine(3, tt.text)
+			if line.Number != 3 || line.Text != tt.text {
+				t.Errorf("ParseLine() kept number %d and text %q", line.Number, line.Text)
+			}
+			if line.Category != tt.category {
+				t.Errorf("category = %q, want %q", line.Category, tt.category)
+			}
+			if line.Level != tt.level {
+				t.Errorf("level = %q, want %q", line.Level, tt.level)
+			}
+		})
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		module   string
+		ok       bool
+	}{
+		{"FactoryGame-SML-Win64-Shipping.dll", "SML", true},
+		{"FactoryGameSteam-RefinedPower-Win64-Shipping.dll", "RefinedPower", true},
+		{"libFactoryServer-PowerSuit-Linux-Shipping.so", "PowerSuit", true},
+		{"FactoryGame-SML-Win64-Shipping.pdb", "SML", true},
+		{"SML.dll", "", false},
+		{"FactoryGame-SML-Win64-Shipping.dll.bak", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			module, ok := ModuleName(tt.fileName)
+			if module != tt.module || ok != tt.ok {
+				t.Errorf("ModuleName() = %q, %v, want %q, %v", module, ok, tt.module, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package gamelog
+
+import (
+	"regexp"
+	"slices"
+)
+
+type Level string
+
+const (
+	LevelFatal       Level = "fatal"
+	LevelError       Level = "error"
+	LevelWarning     Level = "warning"
+	LevelDisplay     Level = "display"
+	LevelLog         Level = "log"
+	LevelVerbose     Level = "verbose"
+	LevelVeryVerbose Level = "veryVerbose"
+)
+
+var AllLevels = []struct {
+	Value  Level
+	TSName string
+}{
+	{LevelFatal, "FATAL"},
+	{LevelError, "ERROR"},
+	{LevelWarning, "WARNING"},
+	{LevelDisplay, "DISPLAY"},
+	{LevelLog, "LOG"},
+	{LevelVerbose, "VERBOSE"},
+	{LevelVeryVerbose, "VERY_VERBOSE"},
+}
+
+// levelOrder is ordered from most to least severe
+var levelOrder = []Level{LevelFatal, LevelError, LevelWarning, LevelDisplay, LevelLog, LevelVerbose, LevelVeryVerbose}
+
+var lineRegex = regexp.MustCompile(`^(?:\[[^\]]*\]\[\s*\d+\])?(\w+): (?:(Fatal|Error|Warning|Display|Verbose|VeryVerbose): )?`)
+
+type Line struct {
+	// Number is 1-based
+	Number int `json:"number"`
+	// Category is empty for lines that are not written by a log category, such as the log header
+	Category string `json:"category"`
+	Level    Level  `json:"level"`
+	Text     string `json:"text"`
+}
+
+// ParseLine reads the category and level of a FactoryGame.log line.
+// Lines without a level are logged at the Log level
+func ParseLine(number int, text string) Line {
+	line := Line{
+		Number: number,
+		Level:  LevelLog,
+		Text:   text,
+	}
+	match := lineRegex.FindStringSubmatch(text)
+	if match == nil {
+		return line
+	}
+	line.Category = match[1]
+	switch match[2] {
+	case "Fatal":
+		line.Level = LevelFatal
+	case "Error":
+		line.Level = LevelError
+	case "Warning":
+		line.Level = LevelWarning
+	case "Display":
+		line.Level = LevelDisplay
+	case "Verbose":
+		line.Level = LevelVerbose
+	case "VeryVerbose":
+		line.Level = LevelVeryVerbose
+	}
+	return line
+}
+
+// ValidLevel returns whether the level is one of the known levels
+func ValidLevel(level Level) bool {
+	return slices.Contains(levelOrder, level)
+}
+
+// AtLeast returns whether the level is at least as severe as minLevel
+func (l Level) AtLeast(minLevel Level) bool {
+	return slices.Index(levelOrder, l) <= slices.Index(levelOrder, minLevel)
+}
@@ -1,5 +1,7 @@
 package common
 
+import "errors"
+
 type GameBranch string
 
 var (
@@ -31,6 +33,37 @@ type Installation struct {
 	Launcher   string       `json:"launcher"`
 	LaunchPath []string     `json:"launchPath"`
 	SavedPath  string       `json:"-"`
+	// LaunchCommand builds the launch command with extra game arguments. Nil if the launcher does not support them,
+	// which is the case for the Epic Games Launcher, whose launch URL has no way to pass arguments to the game,
+	// and for Heroic, which SMM cannot launch at all
+	LaunchCommand func(gameArgs []string) []string `json:"-"`
+	// LaunchEnvSupported is set when the launch command starts the game as its child, so that it inherits the environment.
+	// Launchers started through an URL only hand it to an already running client, which starts the game without it
+	LaunchEnvSupported bool `json:"-"`
+}
+
+var (
+	ErrLaunchArgsUnsupported = errors.New("the launcher of this installation does not support launch arguments")
+	ErrLaunchEnvUnsupported  = errors.New("the launcher of this installation does not pass environment variables to the game")
+)
+
+// BuildLaunchCommand returns the command to launch the install with the extra game arguments
+func (i *Installation) BuildLaunchCommand(gameArgs []string) ([]string, error) {
+	if len(gameArgs) == 0 {
+		return i.LaunchPath, nil
+	}
+	if i.LaunchCommand == nil {
+		return nil, ErrLaunchArgsUnsupported
+	}
+	return i.LaunchCommand(gameArgs), nil
+}
+
+// CheckLaunchEnv returns an error if the environment variables would not reach the game
+func (i *Installation) CheckLaunchEnv(env map[string]string) error {
+	if len(env) > 0 && !i.LaunchEnvSupported {
+		return ErrLaunchEnvUnsupported
+	}
+	return nil
 }
 
 type InstallFindError struct {
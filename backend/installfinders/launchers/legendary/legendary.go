@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"gopkg.in/ini.v1"
 
@@ -88,16 +89,27 @@ func FindInstallationsIn(legendaryDataPath string, launcher string, knownPrefixe
 			continue
 		}
 
-		installs = append(installs, &common.Installation{
+		launchPath := platform.LauncherCommand(legendaryGame.AppName)
+		install := &common.Installation{
 			Path:       installLocation,
 			Version:    version,
 			Type:       installType,
 			Location:   common.LocationTypeLocal,
 			Branch:     branch,
 			Launcher:   launcher,
-			LaunchPath: platform.LauncherCommand(legendaryGame.AppName),
+			LaunchPath: launchPath,
 			SavedPath:  savedPath,
-		})
+		}
+		// Heroic has no launch command, the game can only be launched from Heroic itself
+		if launchPath != nil {
+			install.LaunchCommand = func(gameArgs []string) []string {
+				// legendary passes the arguments after the app name to the game
+				return append(slices.Clone(launchPath), gameArgs...)
+			}
+			// legendary runs the game itself, so the game inherits its environment
+			install.LaunchEnvSupported = true
+		}
+		installs = append(installs, install)
 	}
 	return installs, findErrors
 }
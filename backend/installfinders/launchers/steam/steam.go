@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/andygrunwald/vdf"
 
@@ -118,6 +119,10 @@ func FindInstallationsSteam(steamPath string, launcher string, platform common.L
 				Branch:     branch,
 				Launcher:   launcher,
 				LaunchPath: platform.LauncherCommand(`steam://rungameid/526870`),
+				LaunchCommand: func(gameArgs []string) []string {
+					// rungameid does not accept arguments, but run does, in the path after the app id
+					return platform.LauncherCommand(`steam://run/526870//` + escapeLaunchArgs(strings.Join(gameArgs, " ")) + `/`)
+				},
 				// pass wine platform if necessary, as platform here is going to be native
 				SavedPath: savedPath,
 			})
@@ -127,6 +132,20 @@ func FindInstallationsSteam(steamPath string, launcher string, platform common.L
 	return installs, findErrors
 }
 
+// escapeLaunchArgs percent-encodes everything except letters and digits.
+// On Windows the URL is opened through cmd, so characters like & or | must not reach it unescaped
+func escapeLaunchArgs(args string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(args) {
+		if ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') {
+			escaped.WriteByte(b)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
 func getLibraryFoldersFromManifest(libraryFoldersManifestPath string) ([]string, error) {
 	libraryFoldersF, err := os.Open(libraryFoldersManifestPath)
 	if err != nil {
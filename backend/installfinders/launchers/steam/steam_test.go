@@ -0,0 +1,27 @@
+package steam
+
+import "testing"
+
+func TestEscapeLaunchArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		escaped string
+	}{
+		{"", ""},
+		{"-log", "%2Dlog"},
+		{"-EpicPortal -NoSteamClient", "%2DEpicPortal%20%2DNoSteamClient"},
+		{"-ini:Engine:[Core.Log]:LogNet=Verbose", "%2Dini%3AEngine%3A%5BCore%2ELog%5D%3ALogNet%3DVerbose"},
+		// The arguments end up between slashes in the steam:// URL, so slashes must not stay as they are
+		{"-savedir=C:/Saves", "%2Dsavedir%3DC%3A%2FSaves"},
+		{`-path="C:\Program Files"`, "%2Dpath%3D%22C%3A%5CProgram%20Files%22"},
+		{"-name=Fábrica", "%2Dname%3DF%C3%A1brica"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			if escaped := escapeLaunchArgs(tt.args); escaped != tt.escaped {
+				t.Errorf("escapeLaunchArgs(%q) = %q, want %q", tt.args, escaped, tt.escaped)
+			}
+		})
+	}
+}
@@ -20,9 +20,14 @@ func FindInstallationsWine(winePrefix string, launcher string, launchPath []stri
 		return nil, []error{fmt.Errorf("Steam is not installed in %s", winePrefix)}
 	}
 
-	return FindInstallationsSteam(
+	installs, findErrors := FindInstallationsSteam(
 		steamWinePath,
 		launcher,
 		common.MakeLauncherPlatform(platform, func(_ string) []string { return launchPath }),
 	)
+	// The bottle launch command starts Steam without the steam:// URL, so the game arguments would be lost
+	for _, install := range installs {
+		install.LaunchCommand = nil
+	}
+	return installs, findErrors
 }
@@ -0,0 +1,171 @@
+package savegame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf16"
+)
+
+// Header is the uncompressed start of a .sav file, before the compressed world data
+type Header struct {
+	HeaderVersion int32  `json:"headerVersion"`
+	SaveVersion   int32  `json:"saveVersion"`
+	BuildVersion  int32  `json:"buildVersion"`
+	SaveName      string `json:"saveName"`
+	MapName       string `json:"mapName"`
+	MapOptions    string `json:"mapOptions"`
+	SessionName   string `json:"sessionName"`
+	// PlayDuration is in seconds
+	PlayDuration int32     `json:"playDuration"`
+	SaveDateTime time.Time `json:"saveDateTime"`
+	IsModdedSave bool      `json:"isModdedSave"`
+	// ModMetadata is nil for saves made without SML
+	ModMetadata *ModMetadata `json:"modMetadata"`
+}
+
+// ModMetadata is written by SML into the save header
+type ModMetadata struct {
+	Version int   `json:"Version"`
+	Mods    []Mod `json:"Mods"`
+}
+
+type Mod struct {
+	Reference string `json:"Reference"`
+	Name      string `json:"Name"`
+	Version   string `json:"Version"`
+}
+
+const (
+	headerVersionSessionVisibility = 5
+	headerVersionEditorObject      = 7
+	headerVersionModMetadata       = 8
+	headerVersionSaveName          = 14
+
+	// The save date is stored in .NET ticks, 100ns intervals since 0001-01-01
+	ticksPerSecond   = 10_000_000
+	ticksToUnixEpoch = 621_355_968_000_000_000
+
+	// Strings in the header are short, anything longer means the file is not a save
+	maxStringLength = 1 << 20
+)
+
+var ErrInvalidString = errors.New("invalid string in save header")
+
+// ReadHeaderFile reads the header of the .sav file at path
+func ReadHeaderFile(path string) (*Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open save: %w", err)
+	}
+	defer file.Close()
+	return ReadHeader(file)
+}
+
+// ReadHeader reads only the header, without reading the rest of the save
+func ReadHeader(r io.Reader) (*Header, error) {
+	reader := headerReader{r: bufio.NewReader(r)}
+
+	header := &Header{}
+	header.HeaderVersion = reader.int32()
+	header.SaveVersion = reader.int32()
+	header.BuildVersion = reader.int32()
+	if header.HeaderVersion >= headerVersionSaveName {
+		header.SaveName = reader.string()
+	}
+	header.MapName = reader.string()
+	header.MapOptions = reader.string()
+	header.SessionName = reader.string()
+	header.PlayDuration = reader.int32()
+	ticks := reader.int64()
+	header.SaveDateTime = time.Unix((ticks-ticksToUnixEpoch)/ticksPerSecond, (ticks-ticksToUnixEpoch)%ticksPerSecond*100).UTC()
+	if header.HeaderVersion >= headerVersionSessionVisibility {
+		reader.skip(1)
+	}
+	if header.HeaderVersion >= headerVersionEditorObject {
+		reader.int32()
+	}
+	var modMetadata string
+	if header.HeaderVersion >= headerVersionModMetadata {
+		modMetadata = reader.string()
+		header.IsModdedSave = reader.int32() != 0
+	}
+	if reader.err != nil {
+		return nil, fmt.Errorf("failed to read save header: %w", reader.err)
+	}
+
+	if modMetadata != "" {
+		header.ModMetadata = &ModMetadata{}
+		err := json.Unmarshal([]byte(modMetadata), header.ModMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse save mod metadata: %w", err)
+		}
+	}
+
+	return header, nil
+}
+
+// headerReader keeps the first error, so the fields can be read without checking each one
+type headerReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (h *headerReader) read(data any) {
+	if h.err != nil {
+		return
+	}
+	h.err = binary.Read(h.r, binary.LittleEndian, data)
+}
+
+func (h *headerReader) skip(n int) {
+	if h.err != nil {
+		return
+	}
+	_, h.err = h.r.Discard(n)
+}
+
+func (h *headerReader) int32() int32 {
+	var value int32
+	h.read(&value)
+	return value
+}
+
+func (h *headerReader) int64() int64 {
+	var value int64
+	h.read(&value)
+	return value
+}
+
+// string reads an Unreal FString: a length including the null terminator, negative for UTF-16 strings
+func (h *headerReader) string() string {
+	length := h.int32()
+	if h.err != nil || length == 0 {
+		return ""
+	}
+	if length > maxStringLength || length < -maxStringLength {
+		h.err = ErrInvalidString
+		return ""
+	}
+
+	if length > 0 {
+		data := make([]byte, length)
+		h.read(data)
+		if h.err != nil {
+			return ""
+		}
+		return string(data[:length-1])
+	}
+
+	data := make([]uint16, -length)
+	h.read(data)
+	if h.err != nil {
+		return ""
+	}
+	return string(utf16.Decode(data[:len(data)-1]))
+}
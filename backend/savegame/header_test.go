@@ -0,0 +1,204 @@
+package savegame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// headerWriter writes the fields of a save header the same way the game does
+type headerWriter struct {
+	bytes.Buffer
+}
+
+func (w *headerWriter) int32(value int32) *headerWriter {
+	_ = binary.Write(w, binary.LittleEndian, value)
+	return w
+}
+
+func (w *headerWriter) int64(value int64) *headerWriter {
+	_ = binary.Write(w, binary.LittleEndian, value)
+	return w
+}
+
+func (w *headerWriter) byte(value byte) *headerWriter {
+	w.WriteByte(value)
+	return w
+}
+
+func (w *headerWriter) string(value string) *headerWriter {
+	if value == "" {
+		return w.int32(0)
+	}
+	for _, r := range value {
+		if r > 127 {
+			data := append(utf16.Encode([]rune(value)), 0)
+			w.int32(-int32(len(data)))
+			_ = binary.Write(w, binary.LittleEndian, data)
+			return w
+		}
+	}
+	w.int32(int32(len(value) + 1))
+	w.WriteString(value)
+	w.WriteByte(0)
+	return w
+}
+
+func ticks(t time.Time) int64 {
+	return t.UnixNano()/100 + ticksToUnixEpoch
+}
+
+func TestReadHeader(t *testing.T) {
+	saveTime := time.Date(2024, 9, 10, 12, 30, 15, 500_000_000, time.UTC)
+	modMetadata := `{"Version":1,"Mods":[{"Reference":"SML","Name":"Satisfactory Mod Loader","Version":"3.8.0"},{"Reference":"RefinedPower","Name":"Refined Power","Version":"3.2.1"}]}`
+
+	tests := []struct {
+		name   string
+		data   []byte
+		header *Header
+		// err is a part of the expected error message
+		err string
+	}{
+		{
+			name: "modded save with save name",
+			data: new(headerWriter).
+				int32(14).int32(46).int32(365306).
+				string("MySave_autosave_0").string("Persistent_Level").string("?startloc=Grass Fields").string("My Factory").
+				int32(3600).int64(ticks(saveTime)).
+				byte(1).
+				int32(0).
+				string(modMetadata).int32(1).
+				Bytes(),
+			header: &Header{
+				HeaderVersion: 14,
+				SaveVersion:   46,
+				BuildVersion:  365306,
+				SaveName:      "MySave_autosave_0",
+				MapName:       "Persistent_Level",
+				MapOptions:    "?startloc=Grass Fields",
+				SessionName:   "My Factory",
+				PlayDuration:  3600,
+				SaveDateTime:  saveTime,
+				IsModdedSave:  true,
+				ModMetadata: &ModMetadata{
+					Version: 1,
+					Mods: []Mod{
+						{Reference: "SML", Name: "Satisfactory Mod Loader", Version: "3.8.0"},
+						{Reference: "RefinedPower", Name: "Refined Power", Version: "3.2.1"},
+					},
+				},
+			},
+		},
+		{
+			name: "unmodded save before save names",
+			data: new(headerWriter).
+				int32(13).int32(42).int32(211839).
+				string("Persistent_Level").string("").string("Vanilla").
+				int32(60).int64(ticks(saveTime)).
+				byte(0).
+				int32(0).
+				string("").int32(0).
+				Bytes(),
+			header: &Header{
+				HeaderVersion: 13,
+				SaveVersion:   42,
+				BuildVersion:  211839,
+				MapName:       "Persistent_Level",
+				SessionName:   "Vanilla",
+				PlayDuration:  60,
+				SaveDateTime:  saveTime,
+			},
+		},
+		{
+			name: "utf-16 session name",
+			data: new(headerWriter).
+				int32(14).int32(46).int32(365306).
+				string("Save").string("Persistent_Level").string("").string("Fábrica 工厂").
+				int32(1).int64(ticks(saveTime)).
+				byte(0).
+				int32(0).
+				string("").int32(0).
+				Bytes(),
+			header: &Header{
+				HeaderVersion: 14,
+				SaveVersion:   46,
+				BuildVersion:  365306,
+				SaveName:      "Save",
+				MapName:       "Persistent_Level",
+				SessionName:   "Fábrica 工厂",
+				PlayDuration:  1,
+				SaveDateTime:  saveTime,
+			},
+		},
+		{
+			name: "old header without visibility, editor object or mod metadata",
+			data: new(headerWriter).
+				int32(4).int32(20).int32(100000).
+				string("Persistent_Level").string("").string("Old").
+				int32(10).int64(ticks(saveTime)).
+				Bytes(),
+			header: &Header{
+				HeaderVersion: 4,
+				SaveVersion:   20,
+				BuildVersion:  100000,
+				MapName:       "Persistent_Level",
+				SessionName:   "Old",
+				PlayDuration:  10,
+				SaveDateTime:  saveTime,
+			},
+		},
+		{
+			name: "truncated header",
+			data: new(headerWriter).
+				int32(14).int32(46).int32(365306).
+				string("Save").
+				Bytes(),
+			err: "EOF",
+		},
+		{
+			name: "string too long",
+			data: new(headerWriter).
+				int32(14).int32(46).int32(365306).
+				int32(maxStringLength + 1).
+				Bytes(),
+			err: ErrInvalidString.Error(),
+		},
+		{
+			name: "invalid mod metadata",
+			data: new(headerWriter).
+				int32(14).int32(46).int32(365306).
+				string("Save").string("Persistent_Level").string("").string("Session").
+				int32(1).int64(ticks(saveTime)).
+				byte(0).
+				int32(0).
+				string("{not json").int32(1).
+				Bytes(),
+			err: "failed to parse save mod metadata",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := ReadHeader(bytes.NewReader(tt.data))
+			if tt.err != "" {
+				if err == nil {
+					t.Fatalf("ReadHeader() = %+v, want error %q", header, tt.err)
+				}
+				if !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ReadHeader() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadHeader() error = %v", err)
+			}
+			if !reflect.DeepEqual(header, tt.header) {
+				t.Errorf("ReadHeader() = %+v, want %+v", header, tt.header)
+			}
+		})
+	}
+}
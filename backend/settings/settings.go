@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	psUtilDisk "github.com/shirou/gopsutil/v3/disk"
 	"github.com/spf13/viper"
@@ -35,6 +38,29 @@ var (
 	UpdateAsk      UpdateCheckMode = "ask"
 )
 
+type RepositoryEndpoint struct {
+	APIBase    string `json:"apiBase"`
+	GraphQLAPI string `json:"graphqlApi,omitempty"`
+}
+
+// LaunchOptions are templates, ${install}, ${profile} and ${saved} are replaced when launching
+type LaunchOptions struct {
+	Args []string          `json:"args,omitempty"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+// ServerOptions configure how SMM runs a local dedicated server
+type ServerOptions struct {
+	// Port is the game port, 0 uses the server's default
+	Port int `json:"port,omitempty"`
+	// Multihome is the address the server binds to, empty binds to all addresses
+	Multihome string   `json:"multihome,omitempty"`
+	Log       bool     `json:"log,omitempty"`
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+	// RestartAfterApply starts the server again after an apply stopped it
+	RestartAfterApply bool `json:"restartAfterApply,omitempty"`
+}
+
 type settings struct {
 	WindowPosition        *utils.Position `json:"windowPosition,omitempty"`
 	Maximized             bool            `json:"maximized,omitempty"`
@@ -50,6 +76,22 @@ type settings struct {
 
 	RemoteNames map[string]string `json:"remoteNames,omitempty"`
 
+	// ProfileExtraTargets are targets a profile must stay compatible with, in addition to the targets of the installs using it
+	ProfileExtraTargets map[string][]string `json:"profileExtraTargets,omitempty"`
+
+	// InstallLaunchOptions are keyed by the hash of the install path, since remote paths can contain credentials
+	InstallLaunchOptions map[string]LaunchOptions `json:"installLaunchOptions,omitempty"`
+	// ProfileLaunchOptions are merged over the install's launch options
+	ProfileLaunchOptions map[string]LaunchOptions `json:"profileLaunchOptions,omitempty"`
+
+	// LocalServerOptions are keyed by the hash of the install path, same as InstallLaunchOptions
+	LocalServerOptions map[string]ServerOptions `json:"localServerOptions,omitempty"`
+
+	// DeferApplyWhileGameRunning queues applies blocked by a running game until it exits, instead of failing them
+	DeferApplyWhileGameRunning bool `json:"deferApplyWhileGameRunning,omitempty"`
+	// PreLaunchCheck compares the install's mods with its lockfile before launching
+	PreLaunchCheck bool `json:"preLaunchCheck,omitempty"`
+
 	QueueAutoStart      bool                `json:"queueAutoStart"`
 	IgnoredUpdates      map[string][]string `json:"ignoredUpdates,omitempty"`
 	UpdateCheckMode     UpdateCheckMode     `json:"updateCheckMode,omitempty"`
@@ -61,10 +103,25 @@ type settings struct {
 
 	Proxy string `json:"proxy,omitempty"`
 
+	// RepositoryEndpoints are tried in order, each one is a fallback for the ones before it
+	RepositoryEndpoints []RepositoryEndpoint `json:"repositoryEndpoints,omitempty"`
+
 	Konami       bool   `json:"konami,omitempty"`
 	LaunchButton string `json:"launchButton,omitempty"`
 
 	CacheDir string `json:"cacheDir,omitempty"`
+	// MaxCacheSize is the size in bytes above which unused cached archives are evicted after applying. 0 means no limit
+	MaxCacheSize int64 `json:"maxCacheSize,omitempty"`
+
+	// SaveBackups enables backing up an install's saves before applying changes that remove or downgrade mods
+	SaveBackups bool `json:"saveBackups,omitempty"`
+	// SaveBackupRetention is the number of save backups kept per install. 0 means the default
+	SaveBackupRetention int `json:"saveBackupRetention,omitempty"`
+
+	// RemoteHealthCheckInterval is the number of seconds between checks of remote servers. 0 means the default
+	RemoteHealthCheckInterval int `json:"remoteHealthCheckInterval,omitempty"`
+	// RemoteHealthCheckTimeout is the number of seconds after which a remote server check fails. 0 means the default
+	RemoteHealthCheckTimeout int `json:"remoteHealthCheckTimeout,omitempty"`
 
 	Debug bool `json:"debug,omitempty"`
 
@@ -89,6 +146,13 @@ var Settings = &settings{
 
 	RemoteNames: map[string]string{},
 
+	ProfileExtraTargets: map[string][]string{},
+
+	InstallLaunchOptions: map[string]LaunchOptions{},
+	ProfileLaunchOptions: map[string]LaunchOptions{},
+
+	LocalServerOptions: map[string]ServerOptions{},
+
 	QueueAutoStart:      true,
 	IgnoredUpdates:      map[string][]string{},
 	UpdateCheckMode:     UpdateOnLaunch,
@@ -222,6 +286,24 @@ func (s *settings) SetQueueAutoStart(value bool) {
 	_ = SaveSettings()
 }
 
+func (s *settings) GetDeferApplyWhileGameRunning() bool {
+	return s.DeferApplyWhileGameRunning
+}
+
+func (s *settings) SetDeferApplyWhileGameRunning(value bool) {
+	s.DeferApplyWhileGameRunning = value
+	_ = SaveSettings()
+}
+
+func (s *settings) GetPreLaunchCheck() bool {
+	return s.PreLaunchCheck
+}
+
+func (s *settings) SetPreLaunchCheck(value bool) {
+	s.PreLaunchCheck = value
+	_ = SaveSettings()
+}
+
 func (s *settings) GetIgnoredUpdates() map[string][]string {
 	return s.IgnoredUpdates
 }
@@ -306,6 +388,63 @@ func (s *settings) SetProxy(value string) {
 	_ = SaveSettings()
 }
 
+// GetRepositoryEndpoints returns the configured repository endpoints, or the public API if none are configured
+func (s *settings) GetRepositoryEndpoints() []RepositoryEndpoint {
+	if len(s.RepositoryEndpoints) == 0 {
+		return []RepositoryEndpoint{
+			{
+				APIBase:    viper.GetString("api-base"),
+				GraphQLAPI: viper.GetString("graphql-api"),
+			},
+		}
+	}
+	endpoints := make([]RepositoryEndpoint, 0, len(s.RepositoryEndpoints))
+	for _, endpoint := range s.RepositoryEndpoints {
+		if endpoint.GraphQLAPI == "" {
+			endpoint.GraphQLAPI = viper.GetString("graphql-api")
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+func (s *settings) SetRepositoryEndpoints(endpoints []RepositoryEndpoint) error {
+	validEndpoints := make([]RepositoryEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpoint.APIBase = strings.TrimSuffix(strings.TrimSpace(endpoint.APIBase), "/")
+		endpoint.GraphQLAPI = strings.TrimSpace(endpoint.GraphQLAPI)
+		err := ValidateRepositoryEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(validEndpoints, func(e RepositoryEndpoint) bool { return e.APIBase == endpoint.APIBase }) {
+			continue
+		}
+		validEndpoints = append(validEndpoints, endpoint)
+	}
+	s.RepositoryEndpoints = validEndpoints
+	_ = SaveSettings()
+	wailsRuntime.EventsEmit(common.AppContext, "repositoryEndpoints", s.GetRepositoryEndpoints())
+	return nil
+}
+
+func ValidateRepositoryEndpoint(endpoint RepositoryEndpoint) error {
+	apiBase, err := url.Parse(endpoint.APIBase)
+	if err != nil {
+		return fmt.Errorf("invalid repository URL %s: %w", endpoint.APIBase, err)
+	}
+	if apiBase.Scheme != "http" && apiBase.Scheme != "https" {
+		return fmt.Errorf("invalid repository URL %s: must be http or https", endpoint.APIBase)
+	}
+	if apiBase.Host == "" {
+		return fmt.Errorf("invalid repository URL %s: missing host", endpoint.APIBase)
+	}
+	if endpoint.GraphQLAPI != "" && !strings.HasPrefix(endpoint.GraphQLAPI, "/") {
+		return fmt.Errorf("invalid GraphQL path %s: must start with /", endpoint.GraphQLAPI)
+	}
+	return nil
+}
+
 func (s *settings) SetCacheDir(dir string) error {
 	realDir := dir
 	if dir == "" {
@@ -326,6 +465,84 @@ func (s *settings) GetCacheDir() string {
 	return viper.GetString("cache-dir")
 }
 
+func (s *settings) GetMaxCacheSize() int64 {
+	return s.MaxCacheSize
+}
+
+func (s *settings) SetMaxCacheSize(value int64) error {
+	if value < 0 {
+		return fmt.Errorf("max cache size must not be negative")
+	}
+	s.MaxCacheSize = value
+	_ = SaveSettings()
+	return nil
+}
+
+func (s *settings) GetSaveBackups() bool {
+	return s.SaveBackups
+}
+
+func (s *settings) SetSaveBackups(value bool) {
+	s.SaveBackups = value
+	_ = SaveSettings()
+}
+
+const DefaultSaveBackupRetention = 5
+
+func (s *settings) GetSaveBackupRetention() int {
+	if s.SaveBackupRetention == 0 {
+		return DefaultSaveBackupRetention
+	}
+	return s.SaveBackupRetention
+}
+
+func (s *settings) SetSaveBackupRetention(value int) error {
+	if value < 1 {
+		return fmt.Errorf("at least one save backup must be kept")
+	}
+	s.SaveBackupRetention = value
+	_ = SaveSettings()
+	return nil
+}
+
+const (
+	DefaultRemoteHealthCheckInterval = 60
+	DefaultRemoteHealthCheckTimeout  = 20
+	minRemoteHealthCheckInterval     = 10
+)
+
+func (s *settings) GetRemoteHealthCheckInterval() int {
+	if s.RemoteHealthCheckInterval == 0 {
+		return DefaultRemoteHealthCheckInterval
+	}
+	return s.RemoteHealthCheckInterval
+}
+
+func (s *settings) SetRemoteHealthCheckInterval(value int) error {
+	if value < minRemoteHealthCheckInterval {
+		return fmt.Errorf("remote servers cannot be checked more often than every %d seconds", minRemoteHealthCheckInterval)
+	}
+	s.RemoteHealthCheckInterval = value
+	_ = SaveSettings()
+	return nil
+}
+
+func (s *settings) GetRemoteHealthCheckTimeout() int {
+	if s.RemoteHealthCheckTimeout == 0 {
+		return DefaultRemoteHealthCheckTimeout
+	}
+	return s.RemoteHealthCheckTimeout
+}
+
+func (s *settings) SetRemoteHealthCheckTimeout(value int) error {
+	if value < 1 {
+		return fmt.Errorf("the timeout must be at least one second")
+	}
+	s.RemoteHealthCheckTimeout = value
+	_ = SaveSettings()
+	return nil
+}
+
 func ValidateCacheDir(dir string) error {
 	stat, err := os.Stat(dir)
 	if err != nil {
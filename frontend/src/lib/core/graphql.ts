@@ -5,9 +5,44 @@ import { createClient, fetchExchange } from '@urql/svelte';
 
 import { schema } from '$lib/generated';
 
-export function initializeGraphQLClient(apiEndpointURL: string): Client {
+// Each query is sent to the endpoints in order, moving to the next one when an endpoint is unreachable,
+// fails, or answers with errors. A null field is a valid answer, like a mod that does not exist
+function fallbackFetch(apiEndpointURLs: string[]): typeof fetch {
+  return async (input, init) => {
+    const url = typeof input === 'string' ? input : input instanceof URL ? input.toString() : input.url;
+    let firstResponse: Response | undefined;
+    let lastError: unknown;
+    for (const apiEndpointURL of apiEndpointURLs) {
+      try {
+        const response = await fetch(apiEndpointURL + url.slice(apiEndpointURLs[0].length), init);
+        if (response.ok && !(await hasErrors(response.clone()))) {
+          return response;
+        }
+        firstResponse ??= response;
+      } catch (e) {
+        lastError = e;
+      }
+    }
+    if (firstResponse) {
+      return firstResponse;
+    }
+    throw lastError;
+  };
+}
+
+async function hasErrors(response: Response): Promise<boolean> {
+  try {
+    const body = await response.json();
+    return !!body?.errors?.length || !body?.data;
+  } catch {
+    return false;
+  }
+}
+
+export function initializeGraphQLClient(apiEndpointURLs: string[]): Client {
   return createClient({
-    url: apiEndpointURL,
+    url: apiEndpointURLs[0],
+    fetch: fallbackFetch(apiEndpointURLs),
     exchanges: [
       cacheExchange({
         schema,
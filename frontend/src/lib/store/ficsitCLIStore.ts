@@ -114,6 +114,8 @@ export const progressTitle = derived(progress, ($progress) => {
       return `Importing profile ${$progress.item.name}`;
     case ficsitcli.Action.APPLY:
       return `Applying ${$progress.item.name}`;
+    case ficsitcli.Action.REPAIR:
+      return 'Repairing mods';
   }
 });
 
@@ -161,6 +163,8 @@ const placeholderProgressMessage = derived(progress, ($progress) => {
       return 'Updating...';
     case ficsitcli.Action.APPLY:
       return 'Applying...';
+    case ficsitcli.Action.REPAIR:
+      return 'Repairing...';
     case ficsitcli.Action.TOGGLE_MODS:
       if ($progress.item.name === 'true') {
         return 'Restoring mods...';
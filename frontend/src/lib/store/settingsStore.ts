@@ -10,6 +10,7 @@ import {
   GetKonami,
   GetLanguage,
   GetLaunchButton,
+  GetPreLaunchCheck,
   GetProxy,
   GetQueueAutoStart,
   GetRestoreWindowPosition,
@@ -21,6 +22,7 @@ import {
   SetKonami,
   SetLanguage,
   SetLaunchButton,
+  SetPreLaunchCheck,
   SetProxy,
   SetQueueAutoStart, SetRestoreWindowPosition,
   SetStartView,
@@ -35,9 +37,11 @@ export const konami = bindingTwoWayNoExcept(false, { initialGet: GetKonami }, {
 
 export const launchButton = bindingTwoWayNoExcept<LaunchButtonType>('normal', { initialGet: () => GetLaunchButton().then((l) => l as LaunchButtonType) }, { updateFunction: SetLaunchButton });
 
+export const preLaunchCheck = bindingTwoWayNoExcept(false, { initialGet: GetPreLaunchCheck }, { updateFunction: SetPreLaunchCheck });
+
 export const queueAutoStart = bindingTwoWayNoExcept(true, { initialGet: GetQueueAutoStart }, { updateFunction: SetQueueAutoStart });
 
-export const offline = bindingTwoWayNoExcept<boolean>(false, { initialGet: GetOffline }, { updateFunction: SetOffline });
+export const offline = bindingTwoWayNoExcept<boolean>(false, { initialGet: GetOffline, updateEvent: 'offline' }, { updateFunction: SetOffline });
 
 export const proxy = bindingTwoWayNoExcept<string>('', { initialGet: GetProxy }, { updateFunction: SetProxy });
 
@@ -1,11 +1,11 @@
 import App from './App.svelte';
 
-import { GetAPIEndpoint, GetSiteEndpoint } from '$wailsjs/go/app/app';
+import { GetAPIEndpoints, GetSiteEndpoint } from '$wailsjs/go/app/app';
 
 const app = new App({
   target: document.getElementById('app')!,
   props: {
-    apiEndpointURL: await GetAPIEndpoint(),
+    apiEndpointURLs: await GetAPIEndpoints(),
     siteEndpointURL: await GetSiteEndpoint(),
   },
 });
@@ -26,6 +26,7 @@ import (
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/autoupdate/updater"
 	appCommon "github.com/satisfactorymodding/SatisfactoryModManager/backend/common"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/ficsitcli"
+	"github.com/satisfactorymodding/SatisfactoryModManager/backend/gamelog"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/installfinders/common"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/logging"
 	"github.com/satisfactorymodding/SatisfactoryModManager/backend/migration"
@@ -204,10 +205,13 @@ func main() {
 				wailsextras.WindowSetPosition(ctx, settings.Settings.WindowPosition.X, settings.Settings.WindowPosition.Y)
 			}
 
-			app.App.WatchWindow() //nolint:contextcheck
+			app.App.WatchWindow()  //nolint:contextcheck
+			app.App.WatchCrashes() //nolint:contextcheck
 			go websocket.ListenAndServeWebsocket()
 
-			ficsitcli.FicsitCLI.StartGameRunningWatcher() //nolint:contextcheck
+			ficsitcli.FicsitCLI.StartGameRunningWatcher()  //nolint:contextcheck
+			ficsitcli.FicsitCLI.StartConnectivityWatcher() //nolint:contextcheck
+			ficsitcli.FicsitCLI.StartRemoteServerMonitor() //nolint:contextcheck
 		},
 		OnDomReady: func(_ context.Context) {
 			// OnDomReady is called on every refresh
@@ -215,6 +219,7 @@ func main() {
 		},
 		OnShutdown: func(_ context.Context) {
 			app.App.StopWindowWatcher()
+			ficsitcli.FicsitCLI.StopAllServers()
 		},
 		Bind: []interface{}{
 			app.App,
@@ -230,6 +235,11 @@ func main() {
 			common.AllLocationTypes,
 			ficsitcli.AllInstallationStates,
 			ficsitcli.AllActionTypes,
+			ficsitcli.AllSaveModStatuses,
+			ficsitcli.AllLaunchIssueKinds,
+			ficsitcli.AllServerStatuses,
+			gamelog.AllFindingKinds,
+			gamelog.AllLevels,
 		},
 		Logger: backend.WailsZeroLogLogger{},
 		Debug: options.Debug{